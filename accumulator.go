@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestAccumulator collects fields and counters over the lifetime of a
+// request and logs them as a single summary line on Finish. It is safe for
+// concurrent use.
+type RequestAccumulator struct {
+	mu       sync.Mutex
+	entry    *Entry
+	counters map[string]int64
+	start    time.Time
+	finished bool
+}
+
+func (l *Logger) NewRequestAccumulator() *RequestAccumulator {
+	return l.NewEntry().NewRequestAccumulator()
+}
+
+// NewRequestAccumulator starts a summary carrying the fields of e.
+func (e *Entry) NewRequestAccumulator() *RequestAccumulator {
+	return &RequestAccumulator{
+		entry:    e,
+		counters: map[string]int64{},
+		start:    clock(),
+	}
+}
+
+// With applies fn to the summary entry, so any Entry helper can be used,
+// e.g. a.With(func(e *Entry) *Entry { return e.WithUser(id) }).
+func (a *RequestAccumulator) With(fn func(e *Entry) *Entry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entry = fn(a.entry)
+}
+
+func (a *RequestAccumulator) WithField(field string, value interface{}) {
+	a.With(func(e *Entry) *Entry { return e.WithField(field, value) })
+}
+
+// Add increments counter by n.
+func (a *RequestAccumulator) Add(counter string, n int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counters[counter] += n
+}
+
+// Incr increments counter by one.
+func (a *RequestAccumulator) Incr(counter string) {
+	a.Add(counter, 1)
+}
+
+// Finish logs msg at Info level with the accumulated fields, the counters
+// under counts and the time since the accumulator was created. Calls after
+// the first are ignored.
+func (a *RequestAccumulator) Finish(msg string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.finished {
+		return
+	}
+	a.finished = true
+
+	entry := a.entry.WithDuration(since(a.start))
+	if len(a.counters) > 0 {
+		entry = entry.WithField(FieldCounts, a.counters)
+	}
+	entry.Info(msg)
+}
+
+type requestAccumulatorKey struct{}
+
+// ContextWithRequestAccumulator returns a copy of ctx carrying a, so code
+// deeper in a request can add to the summary a middleware will log, as
+// NewRequestSummaryMiddleware does.
+func ContextWithRequestAccumulator(ctx context.Context, a *RequestAccumulator) context.Context {
+	return context.WithValue(ctx, requestAccumulatorKey{}, a)
+}
+
+// RequestAccumulatorFromContext returns the accumulator in ctx, or nil.
+func RequestAccumulatorFromContext(ctx context.Context) *RequestAccumulator {
+	a, _ := ctx.Value(requestAccumulatorKey{}).(*RequestAccumulator)
+	return a
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAccumulator(t *testing.T) {
+	defer Log.Snapshot()()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	acc := Log.NewEntry().WithHTTPMethod("GET").NewRequestAccumulator()
+	ctx := ContextWithRequestAccumulator(context.Background(), acc)
+
+	RequestAccumulatorFromContext(ctx).Incr("db_queries")
+	RequestAccumulatorFromContext(ctx).Incr("db_queries")
+	RequestAccumulatorFromContext(ctx).Add("warnings", 1)
+	RequestAccumulatorFromContext(ctx).With(func(e *Entry) *Entry { return e.WithUser(10) })
+	now = now.Add(250 * time.Millisecond)
+	acc.Finish("request finished")
+	acc.Finish("request finished")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Equal(t, 1, strings.Count(logFileContent, "\n"), "expected a single summary line")
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(logFileContent), &line))
+	assert.Equal(t, "request finished", line["message"])
+	assert.Equal(t, "GET", line["http.method"])
+	assert.Equal(t, float64(10), line["usr.id"])
+	assert.Equal(t, float64(250*time.Millisecond), line["duration"])
+	assert.Equal(t, map[string]interface{}{"db_queries": float64(2), "warnings": float64(1)}, line["counts"])
+}
+
+func TestRequestAccumulatorFromContextMissing(t *testing.T) {
+	assert.Nil(t, RequestAccumulatorFromContext(context.Background()))
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+type asyncItem struct {
+	line    []byte
+	flushed chan struct{}
+}
+
+// asyncWriter queues writes for a background goroutine so logging never
+// blocks on a slow output. Writes made while the queue is full or after
+// Close are dropped and counted through countDrop, which is called under the
+// logger's lock and must be cheap. onDrop, if set, is told about drops from
+// another goroutine, so it may log through the same logger.
+type asyncWriter struct {
+	out       io.Writer
+	queue     chan asyncItem
+	countDrop func()
+	onDrop    func(count int)
+	done      chan struct{}
+
+	pendingDrops atomic.Uint64
+	dropSignal   chan struct{}
+	dropsDone    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncWriter(out io.Writer, size int, countDrop func(), onDrop func(count int)) *asyncWriter {
+	w := &asyncWriter{
+		out:        out,
+		queue:      make(chan asyncItem, size),
+		countDrop:  countDrop,
+		onDrop:     onDrop,
+		done:       make(chan struct{}),
+		dropSignal: make(chan struct{}, 1),
+		dropsDone:  make(chan struct{}),
+	}
+	go w.run()
+	go w.reportDrops()
+	return w
+}
+
+func (w *asyncWriter) reportDrops() {
+	defer close(w.dropsDone)
+	for range w.dropSignal {
+		if count := w.pendingDrops.Swap(0); count > 0 && w.onDrop != nil {
+			w.onDrop(int(count))
+		}
+	}
+	if count := w.pendingDrops.Swap(0); count > 0 && w.onDrop != nil {
+		w.onDrop(int(count))
+	}
+}
+
+// drop must be called with w.mu held for reading.
+func (w *asyncWriter) drop() {
+	w.countDrop()
+	if w.closed {
+		return
+	}
+	w.pendingDrops.Add(1)
+	select {
+	case w.dropSignal <- struct{}{}:
+	default: // a report is already pending and will include this drop
+	}
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for item := range w.queue {
+		if item.flushed != nil {
+			if f, ok := w.out.(interface{ Flush() error }); ok {
+				_ = f.Flush()
+			}
+			close(item.flushed)
+			continue
+		}
+		_, _ = w.out.Write(item.line)
+	}
+}
+
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.drop()
+		return len(p), nil
+	}
+	// logrus reuses the buffer behind p once Write returns.
+	line := make([]byte, len(p))
+	copy(line, p)
+	select {
+	case w.queue <- asyncItem{line: line}:
+	default:
+		w.drop()
+	}
+	return len(p), nil
+}
+
+// Flush blocks until everything queued before it has been written. Flush
+// after Close is a noop.
+func (w *asyncWriter) Flush() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return nil
+	}
+	flushed := make(chan struct{})
+	w.queue <- asyncItem{flushed: flushed}
+	<-flushed
+	return nil
+}
+
+// Close writes out the queue, reports pending drops and closes the
+// underlying output if it is a io.Closer. Writes after Close are dropped.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+		close(w.dropSignal)
+	}
+	w.mu.Unlock()
+	<-w.done
+	<-w.dropsDone
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// DroppedCount returns how many entries have been dropped because the async
+// queue was full or the output was closed.
+func (l *Logger) DroppedCount() uint64 {
+	return l.stats.dropped.Load()
+}
+
+// BindContext flushes l once ctx is done, so the buffered entries of a
+// cancelled request are not lost in async mode. The flush writes out the
+// whole shared output, not only the entries logged for ctx. Call stop once
+// ctx no longer matters, e.g. when a request completes without being
+// cancelled, to release the registration; it reports whether it stopped the
+// flush from running.
+func (l *Logger) BindContext(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { _ = l.Flush() })
+}
+
+// enableAsync moves all writes to the current output, and to ErrorOutput,
+// onto queues of size entries.
+func (l *Logger) enableAsync(size int, onDrop func(count int)) {
+	countDrop := func() { l.stats.dropped.Add(1) }
+	if h := l.errOutput; h != nil {
+		h.mu.Lock()
+		h.out = newAsyncWriter(h.out, size, countDrop, onDrop)
+		h.mu.Unlock()
+	}
+	l.SetOutput(newAsyncWriter(l.Out, size, countDrop, onDrop))
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingWriter signals each write and blocks until released.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	out     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.release
+	return w.out.Write(p)
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	writer := &blockingWriter{started: make(chan struct{}, 10), release: make(chan struct{})}
+	logger := new(false, LoggingConfig{})
+	logger.Out = writer
+
+	callbackDrops := 0
+	logger.enableAsync(1, func(count int) { callbackDrops += count })
+
+	logger.Info("line 1")
+	<-writer.started // the background writer now holds line 1
+	logger.Info("line 2")
+	logger.Info("line 3")
+	logger.Info("line 4")
+	logger.Info("line 5")
+
+	assert.EqualValues(t, 3, logger.DroppedCount())
+
+	close(writer.release)
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, 3, callbackDrops, "drops not reported by Close")
+	content := writer.out.String()
+	assert.Equal(t, 2, strings.Count(content, "line"))
+	assert.Contains(t, content, "line 1")
+	assert.Contains(t, content, "line 2")
+}
+
+func TestAsyncWriteAfterClose(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{})
+	logger.Out = out
+	logger.enableAsync(10, nil)
+	entry := logger.WithField("usr.id", 10)
+	assert.NoError(t, logger.Close())
+
+	assert.NotPanics(t, func() { entry.Info("after close") })
+	assert.NotContains(t, out.String(), "after close")
+	assert.EqualValues(t, 1, logger.DroppedCount())
+	assert.NoError(t, logger.Flush())
+}
+
+func TestAsyncWriteRacingClose(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	logger.Out = &bytes.Buffer{}
+	logger.enableAsync(10, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			logger.Info("racing")
+		}
+	}()
+	assert.NoError(t, logger.Close())
+	<-done
+}
+
+func TestAsyncOnDropCanLog(t *testing.T) {
+	writer := &blockingWriter{started: make(chan struct{}, 100), release: make(chan struct{})}
+	logger := new(false, LoggingConfig{})
+	logger.Out = writer
+
+	reported := make(chan int, 10)
+	logger.enableAsync(1, func(count int) {
+		logger.WithField("dropped", count).Warn("dropped log lines")
+		select {
+		case reported <- count:
+		default:
+		}
+	})
+
+	logger.Info("line 1")
+	<-writer.started
+	logger.Info("line 2")
+	logger.Info("line 3")
+
+	select {
+	case <-reported:
+	case <-time.After(time.Second):
+		t.Fatal("OnDrop deadlocked logging through its logger")
+	}
+	close(writer.release)
+	assert.NoError(t, logger.Close())
+}
+
+func TestAsyncFlush(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{})
+	logger.Out = out
+	logger.enableAsync(10, nil)
+
+	logger.Info("queued")
+	assert.NoError(t, logger.Flush())
+	assert.Contains(t, out.String(), "queued")
+	assert.EqualValues(t, 0, logger.DroppedCount())
+}
+
+// flushSignalWriter buffers writes and signals every Flush.
+type flushSignalWriter struct {
+	bytes.Buffer
+	flushed chan struct{}
+}
+
+func (w *flushSignalWriter) Flush() error {
+	w.flushed <- struct{}{}
+	return nil
+}
+
+func TestBindContextFlushesOnDone(t *testing.T) {
+	out := &flushSignalWriter{flushed: make(chan struct{}, 1)}
+	logger := new(false, LoggingConfig{})
+	logger.Out = out
+	logger.enableAsync(10, nil)
+	defer logger.Close()
+	<-out.flushed // switching to async flushes the previous output
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := logger.BindContext(ctx)
+	logger.Info("request cancelled")
+	cancel()
+
+	select {
+	case <-out.flushed:
+		assert.Contains(t, out.String(), "request cancelled")
+	case <-time.After(time.Second):
+		t.Fatal("logger was not flushed when the context was done")
+	}
+	assert.False(t, stop(), "the flush already ran")
+}
+
+func TestBindContextStop(t *testing.T) {
+	out := &flushSignalWriter{flushed: make(chan struct{}, 1)}
+	logger := new(false, LoggingConfig{})
+	logger.Out = out
+	logger.enableAsync(10, nil)
+	defer logger.Close()
+	<-out.flushed
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := logger.BindContext(ctx)
+	assert.True(t, stop())
+	cancel()
+
+	select {
+	case <-out.flushed:
+		t.Fatal("logger was flushed after stop")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogStartupBanner logs one Info line summarizing the effective logging
+// configuration, to debug misconfigured services. The Bugsnag API key is
+// never logged, only whether Bugsnag reporting is enabled.
+func (l *Logger) LogStartupBanner() {
+	format := l.config.Format
+	switch {
+	case l.config.Encoder != nil:
+		format = fmt.Sprintf("%T", l.config.Encoder)
+	case format == "":
+		format = "json"
+	}
+	l.NewEntry().WithFields(logrus.Fields{
+		FieldLogLevel:       l.GetLevel().String(),
+		FieldLogFormat:      format,
+		FieldLogOutput:      describeOutput(l.Out),
+		FieldLogAsync:       l.config.AsyncBufferSize > 0,
+		FieldLogSampleRate:  l.config.SampleRate,
+		FieldBugsnagEnabled: l.config.BugsnagAPIKey != "" && l.bugsnag != nil,
+		FieldEnvironment:    l.config.Environment,
+		FieldAppVersion:     l.config.AppVersion,
+	}).Info("logging configured")
+}
+
+func describeOutput(out interface{}) string {
+	switch out := out.(type) {
+	case *os.File:
+		switch out {
+		case os.Stdout:
+			return "stdout"
+		case os.Stderr:
+			return "stderr"
+		}
+		return out.Name()
+	case *compressedWriter:
+		return "gzip " + out.file.Name()
+	case *asyncWriter:
+		return "async " + describeOutput(out.out)
+	}
+	return fmt.Sprintf("%T", out)
+}
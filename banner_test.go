@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogStartupBanner(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(true, LoggingConfig{
+		LogLevel:      "WARNING",
+		Environment:   "staging",
+		AppVersion:    "1.2.3",
+		BugsnagAPIKey: "0123456789abcdef",
+		Format:        "text",
+	})
+	logger.Out = logFile.in
+	logger.SetLevel(logrus.InfoLevel)
+
+	logger.LogStartupBanner()
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, `message="logging configured"`)
+	assert.Contains(t, logFileContent, "log_level=info")
+	assert.Contains(t, logFileContent, "log_format=text")
+	assert.Contains(t, logFileContent, "log_output=")
+	assert.Contains(t, logFileContent, "log_async=false")
+	assert.Contains(t, logFileContent, "bugsnag_enabled=true")
+	assert.Contains(t, logFileContent, "environment=staging")
+	assert.NotContains(t, logFileContent, "0123456789abcdef")
+}
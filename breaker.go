@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBugsnagBreakerThreshold = 5
+	defaultBugsnagBreakerCooldown  = 30 * time.Second
+)
+
+// CircuitState is the state of the circuit breaker guarding Bugsnag.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every notify through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen skips notifies until the cooldown has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single notify through to probe recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker opens after threshold consecutive failures and stays open
+// for cooldown, after which one probe decides whether it closes again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBugsnagBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBugsnagBreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go through. A nil breaker always allows.
+func (c *circuitBreaker) allow() bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case CircuitOpen:
+		if since(c.openedAt) < c.cooldown {
+			return false
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+		return true
+	case CircuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	}
+	return true
+}
+
+// release gives back a call allowed by allow that was not made after all,
+// so a half-open circuit lets the next call probe instead.
+func (c *circuitBreaker) release() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+}
+
+// record registers the result of an allowed call and reports whether it
+// opened the circuit.
+func (c *circuitBreaker) record(err error) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+	if err == nil {
+		c.state = CircuitClosed
+		c.failures = 0
+		return false
+	}
+	c.failures++
+	if c.state == CircuitHalfOpen || c.failures >= c.threshold {
+		opened := c.state != CircuitOpen
+		c.state = CircuitOpen
+		c.openedAt = clock()
+		return opened
+	}
+	return false
+}
+
+func (c *circuitBreaker) State() CircuitState {
+	if c == nil {
+		return CircuitClosed
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// BugsnagCircuitState returns the state of the circuit breaker in front of
+// Bugsnag, or CircuitClosed if l does not report to Bugsnag.
+func (l *Logger) BugsnagCircuitState() CircuitState {
+	if l.bugsnag == nil {
+		return CircuitClosed
+	}
+	return l.bugsnag.breaker.State()
+}
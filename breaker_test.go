@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBugsnagCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	calls := 0
+	var notifyErr error
+	hook := &bugsnagHook{
+		notify: func(error, ...interface{}) error {
+			calls++
+			return notifyErr
+		},
+		timeout: time.Second,
+		breaker: newCircuitBreaker(3, time.Minute),
+	}
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.Hooks.Add(hook)
+	logger.bugsnag = hook
+	fire := func() error {
+		entry := logger.NewEntry().Entry
+		entry.Message = "boom"
+		return hook.Fire(entry)
+	}
+
+	notifyErr = errors.New("bugsnag down")
+	for i := 0; i < 3; i++ {
+		assert.Error(t, fire())
+	}
+	assert.Equal(t, CircuitOpen, logger.BugsnagCircuitState())
+
+	assert.NoError(t, fire(), "an open circuit skips the notify")
+	assert.Equal(t, 3, calls)
+
+	now = now.Add(time.Minute)
+	assert.Error(t, fire(), "the probe after the cooldown fails")
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, CircuitOpen, logger.BugsnagCircuitState())
+
+	now = now.Add(time.Minute)
+	notifyErr = nil
+	assert.NoError(t, fire())
+	assert.Equal(t, 5, calls)
+	assert.Equal(t, CircuitClosed, logger.BugsnagCircuitState())
+}
+
+func TestCircuitBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	breaker := newCircuitBreaker(1, time.Minute)
+	assert.True(t, breaker.allow())
+	assert.True(t, breaker.record(errors.New("down")))
+	assert.False(t, breaker.allow())
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.allow())
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.False(t, breaker.allow(), "only one probe at a time")
+}
+
+func TestBugsnagCircuitStateWithoutBugsnag(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	logger.Level = logrus.InfoLevel
+	assert.Equal(t, CircuitClosed, logger.BugsnagCircuitState())
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize keeps the rare huge entry from pinning its buffer in
+// the pools.
+const maxPooledBufferSize = 64 << 10
+
+var (
+	bufferPool  = sync.Pool{New: func() interface{} { return &bytes.Buffer{} }}
+	scratchPool = sync.Pool{New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	}}
+)
+
+// getBuffer returns an empty buffer to format an entry into, for the places
+// where logrus does not hand the formatter one from its own pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// getScratch returns an empty byte slice to build an encoding in. The
+// result must be copied before putScratch is called.
+func getScratch() *[]byte {
+	b := scratchPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+func putScratch(b *[]byte) {
+	if cap(*b) <= maxPooledBufferSize {
+		scratchPool.Put(b)
+	}
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var loggingPackage = currentPackageName()
+
+func currentPackageName() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	return name[:strings.LastIndexByte(name, '.')]
+}
+
+// callerFrame returns the frame skip levels above the first frame outside
+// logrus and this package.
+func callerFrame(skip int) (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	found := false
+	for {
+		frame, more := frames.Next()
+		if !found {
+			inLogrus := strings.HasPrefix(frame.Function, "github.com/sirupsen/logrus.")
+			found = !inLogrus && !inLoggingPackage(frame)
+		}
+		if found {
+			if skip == 0 {
+				return frame, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// inLoggingPackage reports whether frame is in this package, tests aside.
+func inLoggingPackage(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, loggingPackage+".") &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// callerOutsideLogging returns file:line of the first frame outside logrus
+// and this package.
+func callerOutsideLogging() string {
+	frame, ok := callerFrame(0)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+}
+
+type stackDepthKey struct{}
+
+// WithStackDepth makes the entry attribute itself additional frames further
+// up the stack, for helpers wrapping our logging calls. It applies to the
+// reported caller and to the stack traces sent to Bugsnag, and accumulates
+// across calls.
+func (e *Entry) WithStackDepth(additional int) *Entry {
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	depth := stackDepth(ctx) + additional
+	return &Entry{e.Entry.WithContext(context.WithValue(ctx, stackDepthKey{}, depth))}
+}
+
+func stackDepth(ctx context.Context) int {
+	if ctx == nil {
+		return 0
+	}
+	depth, _ := ctx.Value(stackDepthKey{}).(int)
+	return depth
+}
+
+// stackDepthHook corrects the caller logrus reports for entries created
+// with WithStackDepth, and for entries logged through the Logger methods of
+// this package, which logrus would report as the caller.
+type stackDepthHook struct{}
+
+func (h *stackDepthHook) Fire(entry *logrus.Entry) error {
+	depth := stackDepth(entry.Context)
+	if entry.Caller == nil || (depth == 0 && !inLoggingPackage(*entry.Caller)) {
+		return nil
+	}
+	if frame, ok := callerFrame(depth); ok {
+		entry.Caller = &frame
+	}
+	return nil
+}
+
+func (h *stackDepthHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logThroughWrapper is a helper like the ones teams build around Entry.
+func logThroughWrapper(e *Entry, msg string) {
+	e.WithStackDepth(1).Info(msg)
+}
+
+func TestWithStackDepth(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{})
+	logger.Out = logFile.in
+	logger.SetReportCaller(true)
+
+	_, file, line, _ := runtime.Caller(0)
+	logThroughWrapper(logger.NewEntry(), "wrapped")
+	logger.Info("direct")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"logger.name":"%s:%d"`, file, line+1))
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"logger.name":"%s:%d"`, file, line+2))
+}
+
+func TestWithStackDepthAccumulates(t *testing.T) {
+	entry := Log.NewEntry().WithStackDepth(1).WithStackDepth(2)
+	assert.Equal(t, 3, stackDepth(entry.Context))
+}
@@ -0,0 +1,40 @@
+package logging
+
+import "net/http"
+
+// Headers our mobile clients send their platform and app version in, unless
+// LoggingConfig.ClientPlatformHeader and ClientVersionHeader say otherwise.
+const (
+	DefaultClientPlatformHeader = "X-Client-Platform"
+	DefaultClientVersionHeader  = "X-Client-Version"
+)
+
+// WithClient adds the platform and app version of the calling client,
+// dropping empty values.
+func (e *Entry) WithClient(platform string, version string) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldClientPlatform, platform).
+		WithStringFieldIgnoreEmpty(FieldClientVersion, version)
+}
+
+// WithClientFromHeaders adds the client platform and version found in the
+// DefaultClientPlatformHeader and DefaultClientVersionHeader request
+// headers. Use Logger.WithClientFromHeaders to honour the headers in the
+// logger's config.
+func (e *Entry) WithClientFromHeaders(h http.Header) *Entry {
+	return e.WithClient(h.Get(DefaultClientPlatformHeader), h.Get(DefaultClientVersionHeader))
+}
+
+// WithClientFromHeaders returns an entry with the client platform and
+// version found in the request headers configured by
+// LoggingConfig.ClientPlatformHeader and ClientVersionHeader.
+func (l *Logger) WithClientFromHeaders(h http.Header) *Entry {
+	platform, version := l.config.ClientPlatformHeader, l.config.ClientVersionHeader
+	if platform == "" {
+		platform = DefaultClientPlatformHeader
+	}
+	if version == "" {
+		version = DefaultClientVersionHeader
+	}
+	return l.NewEntry().WithClient(h.Get(platform), h.Get(version))
+}
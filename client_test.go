@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithClient(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+
+	t.Run("fields present if non empty", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithClient("ios", "7.3.0").Info("request")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"client_platform":"ios"`)
+		assert.Contains(t, logFileContent, `"client_version":"7.3.0"`)
+	})
+	t.Run("empty values dropped", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithClient("android", "").Info("request")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"client_platform":"android"`)
+		assert.NotContains(t, logFileContent, "client_version")
+	})
+}
+
+func TestWithClientFromHeaders(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+
+	t.Run("default headers", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		h := http.Header{}
+		h.Set("X-Client-Platform", "ios")
+		h.Set("X-Client-Version", "7.3.0")
+		Log.NewEntry().WithClientFromHeaders(h).Info("request")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"client_platform":"ios"`)
+		assert.Contains(t, logFileContent, `"client_version":"7.3.0"`)
+	})
+	t.Run("configured headers", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		logger := new(false, LoggingConfig{ClientPlatformHeader: "X-Platform", ClientVersionHeader: "X-App-Version"})
+		logger.Out = logFile.in
+
+		h := http.Header{}
+		h.Set("X-Platform", "android")
+		h.Set("X-App-Version", "7.2.1")
+		logger.WithClientFromHeaders(h).Info("request")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"client_platform":"android"`)
+		assert.Contains(t, logFileContent, `"client_version":"7.2.1"`)
+	})
+}
@@ -0,0 +1,20 @@
+package logging
+
+import "time"
+
+// clock is the time source for every time-dependent feature in this package.
+var clock = time.Now
+
+// SetClockForTest replaces the package clock so tests can freeze or advance
+// time. Passing nil restores time.Now. It is not safe to call while other
+// goroutines are logging.
+func SetClockForTest(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	clock = now
+}
+
+func since(t time.Time) time.Duration {
+	return clock().Sub(t)
+}
@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetClockForTest(t *testing.T) {
+	frozen := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return frozen })
+	defer SetClockForTest(nil)
+
+	windowStart := frozen.Add(-30 * time.Second)
+	window := time.Minute
+	assert.Equal(t, 30*time.Second, since(windowStart))
+	assert.True(t, since(windowStart) < window, "should still be inside the window")
+
+	frozen = frozen.Add(time.Minute)
+	assert.Equal(t, 90*time.Second, since(windowStart))
+	assert.False(t, since(windowStart) < window, "should have left the window")
+}
@@ -0,0 +1,301 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCloudWatchFlushInterval = 5 * time.Second
+	defaultCloudWatchTimeout       = 10 * time.Second
+	// PutLogEvents limits, counting 26 bytes of overhead per event.
+	cloudWatchMaxBatchEvents   = 10000
+	cloudWatchMaxBatchBytes    = 1 << 20
+	cloudWatchEventOverhead    = 26
+	cloudWatchMaxMessageLength = 256*1024 - cloudWatchEventOverhead
+)
+
+// CloudWatchLogEvent is a single line sent to CloudWatch Logs. Timestamp is
+// in milliseconds since the epoch.
+type CloudWatchLogEvent struct {
+	Timestamp int64
+	Message   string
+}
+
+// CloudWatchPutLogEventsInput mirrors the CloudWatch Logs PutLogEvents
+// request. Events are in chronological order.
+type CloudWatchPutLogEventsInput struct {
+	LogGroupName  string
+	LogStreamName string
+	LogEvents     []CloudWatchLogEvent
+	SequenceToken *string
+}
+
+// CloudWatchClient sends a batch of events to CloudWatch Logs, returning
+// the sequence token for the next batch. It is usually a thin adapter around
+// the AWS SDK's cloudwatchlogs client, which keeps the SDK out of this
+// module. An adapter should return a *CloudWatchSequenceTokenError when the
+// token was rejected.
+type CloudWatchClient interface {
+	PutLogEvents(ctx context.Context, input CloudWatchPutLogEventsInput) (nextSequenceToken *string, err error)
+}
+
+// CloudWatchSequenceTokenError reports that a batch was sent with the wrong
+// sequence token, e.g. because another process wrote to the stream.
+type CloudWatchSequenceTokenError struct {
+	ExpectedSequenceToken *string
+}
+
+func (e *CloudWatchSequenceTokenError) Error() string {
+	return "cloudwatch: invalid sequence token"
+}
+
+// cloudWatchHook batches entries and sends them to a CloudWatch log stream
+// when a batch is full, every interval, and on Close. Batches are sent by a
+// background goroutine, so logging never waits on PutLogEvents. A batch
+// that fails to send is put back for the next flush. While a full batch is
+// waiting to be sent, or until a send succeeds again, the oldest events of
+// the next batch are dropped to make room and counted instead.
+type cloudWatchHook struct {
+	client    CloudWatchClient
+	group     string
+	stream    string
+	formatter logrus.Formatter
+	timeout   time.Duration
+
+	mu         sync.Mutex
+	batch      []CloudWatchLogEvent
+	batchBytes int
+	// ready is a full batch handed to the background goroutine.
+	ready   []CloudWatchLogEvent
+	failing bool
+	lost    atomic.Uint64
+	// flushNow wakes the background goroutine when ready is set.
+	flushNow chan struct{}
+
+	sendMu sync.Mutex
+	token  *string
+
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newCloudWatchHook(client CloudWatchClient, group, stream string, formatter logrus.Formatter, flushInterval time.Duration) *cloudWatchHook {
+	h := &cloudWatchHook{
+		client:    client,
+		group:     group,
+		stream:    stream,
+		formatter: formatter,
+		timeout:   defaultCloudWatchTimeout,
+		flushNow:  make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go h.flushPeriodically(flushInterval)
+	return h
+}
+
+func (h *cloudWatchHook) flushPeriodically(interval time.Duration) {
+	defer close(h.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = h.Flush()
+		case <-h.flushNow:
+			_ = h.Flush()
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *cloudWatchHook) Fire(entry *logrus.Entry) error {
+	if isReplayed(entry) || isSampledOut(entry) {
+		return nil
+	}
+	// Hooks get an entry without a buffer, so lend the formatter one.
+	if entry.Buffer == nil {
+		buf := getBuffer()
+		entry.Buffer = buf
+		defer func() {
+			entry.Buffer = nil
+			putBuffer(buf)
+		}()
+	}
+	serialized, err := h.formatter.Format(entry)
+	if err != nil || len(serialized) == 0 {
+		return err
+	}
+	event := CloudWatchLogEvent{
+		Timestamp: entry.Time.UnixMilli(),
+		Message:   truncateString(string(serialized), cloudWatchMaxMessageLength),
+	}
+	size := len(event.Message) + cloudWatchEventOverhead
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.batch) == cloudWatchMaxBatchEvents || h.batchBytes+size > cloudWatchMaxBatchBytes {
+		if h.failing || h.ready != nil {
+			h.trim(1, size)
+		} else {
+			h.ready = h.takeBatch()
+			select {
+			case h.flushNow <- struct{}{}:
+			default:
+			}
+		}
+	}
+	h.batch = append(h.batch, event)
+	h.batchBytes += size
+	return nil
+}
+
+// trim drops the oldest pending events until another events events of size
+// bytes in total fit in the batch, counting them as lost. It must be called
+// with h.mu held.
+func (h *cloudWatchHook) trim(events int, size int) {
+	dropped := 0
+	for len(h.batch) > 0 &&
+		(len(h.batch)+events > cloudWatchMaxBatchEvents || h.batchBytes+size > cloudWatchMaxBatchBytes) {
+		h.batchBytes -= len(h.batch[0].Message) + cloudWatchEventOverhead
+		h.batch = h.batch[1:]
+		dropped++
+	}
+	h.lost.Add(uint64(dropped))
+}
+
+// requeue puts a batch that failed to send back ahead of the events logged
+// since, dropping the oldest events if they no longer fit.
+func (h *cloudWatchHook) requeue(batch []CloudWatchLogEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failing = true
+	size := 0
+	for _, event := range batch {
+		size += len(event.Message) + cloudWatchEventOverhead
+	}
+	h.batch = append(batch, h.batch...)
+	h.batchBytes += size
+	h.trim(0, 0)
+}
+
+// takeBatch must be called with h.mu held.
+func (h *cloudWatchHook) takeBatch() []CloudWatchLogEvent {
+	batch := h.batch
+	h.batch = nil
+	h.batchBytes = 0
+	return batch
+}
+
+// Flush sends the full batch waiting to be sent and the pending batch, if
+// any, putting back what could not be sent.
+func (h *cloudWatchHook) Flush() error {
+	h.mu.Lock()
+	batches := [][]CloudWatchLogEvent{h.ready, h.takeBatch()}
+	h.ready = nil
+	h.mu.Unlock()
+	for i, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+		// Entries logged concurrently may be appended slightly out of order.
+		sort.SliceStable(batch, func(i, j int) bool { return batch[i].Timestamp < batch[j].Timestamp })
+		if err := h.put(batch); err != nil {
+			for _, unsent := range batches[i+1:] {
+				batch = append(batch, unsent...)
+			}
+			h.requeue(batch)
+			return err
+		}
+		h.mu.Lock()
+		h.failing = false
+		h.mu.Unlock()
+	}
+	return nil
+}
+
+// put sends batch, retrying once with the expected sequence token if the one
+// held was rejected.
+func (h *cloudWatchHook) put(batch []CloudWatchLogEvent) error {
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		var next *string
+		next, err = h.client.PutLogEvents(ctx, CloudWatchPutLogEventsInput{
+			LogGroupName:  h.group,
+			LogStreamName: h.stream,
+			LogEvents:     batch,
+			SequenceToken: h.token,
+		})
+		cancel()
+		var tokenErr *CloudWatchSequenceTokenError
+		if errors.As(err, &tokenErr) {
+			h.token = tokenErr.ExpectedSequenceToken
+			continue
+		}
+		if err == nil {
+			h.token = next
+		}
+		return err
+	}
+	return err
+}
+
+// Close stops the periodic flush and sends the pending batch. Events that
+// still cannot be sent are counted as lost.
+func (h *cloudWatchHook) Close() error {
+	h.stopOnce.Do(func() { close(h.done) })
+	<-h.stopped
+	err := h.Flush()
+	if err != nil {
+		h.mu.Lock()
+		h.lost.Add(uint64(len(h.takeBatch())))
+		h.mu.Unlock()
+	}
+	return err
+}
+
+// CloudWatchLostCount returns how many entries could not be sent to
+// CloudWatch and were dropped, or 0 if the export is not enabled.
+func (l *Logger) CloudWatchLostCount() uint64 {
+	if l.cloudWatch == nil {
+		return 0
+	}
+	return l.cloudWatch.lost.Load()
+}
+
+func (h *cloudWatchHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// enableCloudWatch sends every entry, formatted as for the output but
+// including the Error entries split off to ErrorOutput, to the stream
+// configured in config. Pending entries are sent by Close.
+func (l *Logger) enableCloudWatch(config LoggingConfig) error {
+	if config.NewCloudWatchClient == nil {
+		return errors.New("CloudWatchGroup requires NewCloudWatchClient")
+	}
+	if config.CloudWatchStream == "" {
+		return errors.New("CloudWatchGroup requires CloudWatchStream")
+	}
+	client, err := config.NewCloudWatchClient(config.CloudWatchRegion)
+	if err != nil {
+		return err
+	}
+	hook := newCloudWatchHook(client, config.CloudWatchGroup, config.CloudWatchStream, l.entryFormatter(), defaultCloudWatchFlushInterval)
+	l.Hooks.Add(hook)
+	l.cloudWatch = hook
+	l.onClose = append(l.onClose, hook.Close)
+	return nil
+}
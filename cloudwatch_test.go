@@ -0,0 +1,201 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCloudWatchClient struct {
+	mu       sync.Mutex
+	inputs   []CloudWatchPutLogEventsInput
+	expected *string
+	next     int
+	err      error
+}
+
+func (c *mockCloudWatchClient) PutLogEvents(ctx context.Context, input CloudWatchPutLogEventsInput) (*string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if (input.SequenceToken == nil) != (c.expected == nil) ||
+		(c.expected != nil && *input.SequenceToken != *c.expected) {
+		return nil, &CloudWatchSequenceTokenError{ExpectedSequenceToken: c.expected}
+	}
+	c.inputs = append(c.inputs, input)
+	c.next++
+	token := strings.Repeat("t", c.next)
+	c.expected = &token
+	return &token, nil
+}
+
+func (c *mockCloudWatchClient) sent() []CloudWatchPutLogEventsInput {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CloudWatchPutLogEventsInput(nil), c.inputs...)
+}
+
+func TestCloudWatchHook(t *testing.T) {
+	client := &mockCloudWatchClient{}
+	logger := new(false, LoggingConfig{})
+	logger.Out = &strings.Builder{}
+	logger.Level = logrus.InfoLevel
+	hook := newCloudWatchHook(client, "group", "stream", logger.Formatter, time.Hour)
+	logger.Hooks.Add(hook)
+
+	logger.WithField("usr.id", 10).Info("first")
+	logger.Info("second")
+	assert.Empty(t, client.sent(), "sent before the batch was flushed")
+
+	assert.NoError(t, hook.Flush())
+	sent := client.sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "group", sent[0].LogGroupName)
+	assert.Equal(t, "stream", sent[0].LogStreamName)
+	assert.Nil(t, sent[0].SequenceToken)
+	assert.Len(t, sent[0].LogEvents, 2)
+	assert.Contains(t, sent[0].LogEvents[0].Message, `"message":"first"`)
+	assert.Contains(t, sent[0].LogEvents[0].Message, `"usr.id":10`)
+
+	logger.Info("third")
+	assert.NoError(t, hook.Close())
+	sent = client.sent()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "t", *sent[1].SequenceToken)
+}
+
+func TestCloudWatchHookSequenceToken(t *testing.T) {
+	token := "from another writer"
+	client := &mockCloudWatchClient{expected: &token}
+	hook := newCloudWatchHook(client, "group", "stream", &logrus.JSONFormatter{}, time.Hour)
+	defer hook.Close()
+
+	assert.NoError(t, hook.Fire(&logrus.Entry{Time: time.Now(), Message: "line"}))
+	assert.NoError(t, hook.Flush())
+	sent := client.sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, token, *sent[0].SequenceToken)
+}
+
+func TestCloudWatchHookFullBatch(t *testing.T) {
+	client := &mockCloudWatchClient{}
+	hook := newCloudWatchHook(client, "group", "stream", &logrus.TextFormatter{DisableTimestamp: true}, time.Hour)
+	defer hook.Close()
+
+	now := time.Now()
+	for i := 0; i < cloudWatchMaxBatchEvents; i++ {
+		assert.NoError(t, hook.Fire(&logrus.Entry{Time: now, Message: "line"}))
+	}
+	client.mu.Lock()
+	// Fire must not wait for the send the full batch triggers.
+	assert.NoError(t, hook.Fire(&logrus.Entry{Time: now, Message: "line"}))
+	client.mu.Unlock()
+
+	assert.Eventually(t, func() bool { return len(client.sent()) > 0 }, time.Second, time.Millisecond,
+		"the full batch is sent in the background")
+	assert.Len(t, client.sent()[0].LogEvents, cloudWatchMaxBatchEvents)
+}
+
+func TestEnableCloudWatch(t *testing.T) {
+	client := &mockCloudWatchClient{}
+	var region string
+	logger := new(false, LoggingConfig{})
+	logger.Out = &strings.Builder{}
+	err := logger.enableCloudWatch(LoggingConfig{
+		CloudWatchGroup:  "group",
+		CloudWatchStream: "stream",
+		CloudWatchRegion: "eu-west-1",
+		NewCloudWatchClient: func(r string) (CloudWatchClient, error) {
+			region = r
+			return client, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	logger.Warn("shipped on close")
+	assert.NoError(t, logger.Close())
+	sent := client.sent()
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent[0].LogEvents[0].Message, "shipped on close")
+
+	assert.EqualError(t, logger.enableCloudWatch(LoggingConfig{CloudWatchGroup: "group"}), "CloudWatchGroup requires NewCloudWatchClient")
+}
+
+func (c *mockCloudWatchClient) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func TestCloudWatchHookRequeuesFailedBatch(t *testing.T) {
+	client := &mockCloudWatchClient{err: errors.New("throttled")}
+	hook := newCloudWatchHook(client, "group", "stream", &logrus.JSONFormatter{}, time.Hour)
+	defer hook.Close()
+
+	assert.NoError(t, hook.Fire(&logrus.Entry{Time: time.Now(), Message: "first"}))
+	assert.EqualError(t, hook.Flush(), "throttled")
+	assert.NoError(t, hook.Fire(&logrus.Entry{Time: time.Now(), Message: "second"}))
+
+	client.setErr(nil)
+	assert.NoError(t, hook.Flush())
+	sent := client.sent()
+	assert.Len(t, sent, 1)
+	assert.Len(t, sent[0].LogEvents, 2)
+	assert.Contains(t, sent[0].LogEvents[0].Message, "first")
+	assert.Zero(t, hook.lost.Load())
+}
+
+func TestCloudWatchHookDropsOldestWhileFailing(t *testing.T) {
+	client := &mockCloudWatchClient{err: errors.New("unreachable")}
+	hook := newCloudWatchHook(client, "group", "stream", &logrus.TextFormatter{DisableTimestamp: true}, time.Hour)
+
+	now := time.Now()
+	for i := 0; i <= cloudWatchMaxBatchEvents; i++ {
+		assert.NoError(t, hook.Fire(&logrus.Entry{Time: now, Message: "line"}))
+	}
+	assert.Eventually(t, func() bool {
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		return hook.failing
+	}, time.Second, time.Millisecond, "the full batch was not sent in the background")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, hook.Fire(&logrus.Entry{Time: now, Message: "line"}), "full batch sent while failing")
+	}
+	assert.EqualValues(t, 6, hook.lost.Load())
+
+	assert.EqualError(t, hook.Close(), "unreachable")
+	assert.EqualValues(t, 6+cloudWatchMaxBatchEvents, hook.lost.Load())
+}
+
+func TestEnableCloudWatchWithSplitOutput(t *testing.T) {
+	client := &mockCloudWatchClient{}
+	errOut := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SplitOutputByLevel: true, ErrorOutput: errOut})
+	logger.Out = &strings.Builder{}
+	assert.NoError(t, logger.enableCloudWatch(LoggingConfig{
+		CloudWatchGroup:     "group",
+		CloudWatchStream:    "stream",
+		NewCloudWatchClient: func(string) (CloudWatchClient, error) { return client, nil },
+	}))
+
+	logger.Info("info line")
+	logger.Error("error line")
+	assert.NoError(t, logger.Close())
+
+	assert.Equal(t, 1, strings.Count(errOut.String(), "error line"))
+	sent := client.sent()
+	assert.Len(t, sent, 1)
+	assert.Len(t, sent[0].LogEvents, 2)
+	assert.Contains(t, sent[0].LogEvents[1].Message, "error line")
+	assert.Zero(t, logger.CloudWatchLostCount())
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ConfigBuilder builds a LoggingConfig, checking it for mistakes that would
+// otherwise be silently ignored, such as an unknown level or format.
+type ConfigBuilder struct {
+	config LoggingConfig
+}
+
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{}
+}
+
+// WithLevel sets the level: one of ERROR, WARNING, INFO or DEBUG.
+func (b *ConfigBuilder) WithLevel(level string) *ConfigBuilder {
+	b.config.LogLevel = level
+	return b
+}
+
+func (b *ConfigBuilder) WithEnvironment(environment string) *ConfigBuilder {
+	b.config.Environment = environment
+	return b
+}
+
+func (b *ConfigBuilder) WithAppVersion(version string) *ConfigBuilder {
+	b.config.AppVersion = version
+	return b
+}
+
+// WithBugsnag enables Bugsnag reporting with apiKey, for the release stages
+// given, or every stage if none are.
+func (b *ConfigBuilder) WithBugsnag(apiKey string, notifyReleaseStages ...string) *ConfigBuilder {
+	b.config.BugsnagAPIKey = apiKey
+	b.config.BugsnagNotifyReleaseStages = notifyReleaseStages
+	return b
+}
+
+// WithFormat sets the format: "json" or "text".
+func (b *ConfigBuilder) WithFormat(format string) *ConfigBuilder {
+	b.config.Format = format
+	return b
+}
+
+func (b *ConfigBuilder) WithEncoder(encoder Encoder) *ConfigBuilder {
+	b.config.Encoder = encoder
+	return b
+}
+
+func (b *ConfigBuilder) WithOutputFile(path string) *ConfigBuilder {
+	b.config.OutputFile = path
+	return b
+}
+
+// WithCompression gzips the output file.
+func (b *ConfigBuilder) WithCompression() *ConfigBuilder {
+	b.config.CompressOutput = true
+	return b
+}
+
+// WithSplitOutput writes Error and above to errOut and the rest to stdout.
+func (b *ConfigBuilder) WithSplitOutput(errOut io.Writer) *ConfigBuilder {
+	b.config.SplitOutputByLevel = true
+	b.config.ErrorOutput = errOut
+	return b
+}
+
+func (b *ConfigBuilder) WithAsync(bufferSize int, onDrop func(count int)) *ConfigBuilder {
+	b.config.AsyncBufferSize = bufferSize
+	b.config.OnDrop = onDrop
+	return b
+}
+
+func (b *ConfigBuilder) WithSampleRate(rate float64) *ConfigBuilder {
+	b.config.SampleRate = rate
+	return b
+}
+
+// With applies fn to the config for the options without a builder method.
+func (b *ConfigBuilder) With(fn func(config *LoggingConfig)) *ConfigBuilder {
+	fn(&b.config)
+	return b
+}
+
+// Build returns the config, or an error listing every problem found.
+func (b *ConfigBuilder) Build() (LoggingConfig, error) {
+	return b.config, validateConfig(b.config)
+}
+
+func validateConfig(config LoggingConfig) error {
+	var errs []error
+	if !isKnownLogLevel(config.LogLevel) {
+		errs = append(errs, fmt.Errorf("unknown LogLevel %q", config.LogLevel))
+	}
+	switch config.Format {
+	case "", "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("unknown Format %q", config.Format))
+	}
+	if config.Format != "" && config.Encoder != nil {
+		errs = append(errs, errors.New("Format and Encoder are mutually exclusive"))
+	}
+	if config.Color != nil && config.Format != "text" {
+		errs = append(errs, errors.New("Color requires the text Format"))
+	}
+	switch config.FatalBehavior {
+	case "", "exit", "panic", "noop":
+	default:
+		errs = append(errs, fmt.Errorf("unknown FatalBehavior %q", config.FatalBehavior))
+	}
+	if config.CompressOutput && config.OutputFile == "" {
+		errs = append(errs, errors.New("CompressOutput requires an OutputFile"))
+	}
+	if config.ErrorOutput != nil && !config.SplitOutputByLevel {
+		errs = append(errs, errors.New("ErrorOutput requires SplitOutputByLevel"))
+	}
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"AsyncBufferSize", config.AsyncBufferSize},
+		{"MaxFields", config.MaxFields},
+		{"MaxMessageLength", config.MaxMessageLength},
+		{"MaxFieldValueLength", config.MaxFieldValueLength},
+	} {
+		if limit.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", limit.name))
+		}
+	}
+	if config.OnDrop != nil && config.AsyncBufferSize == 0 {
+		errs = append(errs, errors.New("OnDrop requires an AsyncBufferSize"))
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		errs = append(errs, fmt.Errorf("SampleRate %v is outside [0, 1]", config.SampleRate))
+	}
+	if len(config.BugsnagNotifyReleaseStages) > 0 && config.BugsnagAPIKey == "" {
+		errs = append(errs, errors.New("BugsnagNotifyReleaseStages requires a BugsnagAPIKey"))
+	}
+	if config.CloudWatchGroup != "" && (config.CloudWatchStream == "" || config.NewCloudWatchClient == nil) {
+		errs = append(errs, errors.New("CloudWatchGroup requires a CloudWatchStream and NewCloudWatchClient"))
+	}
+	if config.CloudWatchGroup == "" && (config.CloudWatchStream != "" || config.NewCloudWatchClient != nil) {
+		errs = append(errs, errors.New("CloudWatchStream and NewCloudWatchClient require a CloudWatchGroup"))
+	}
+	return errors.Join(errs...)
+}
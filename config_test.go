@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		errOut := &bytes.Buffer{}
+		config, err := NewConfigBuilder().
+			WithLevel("DEBUG").
+			WithEnvironment("production").
+			WithBugsnag("key", "production").
+			WithFormat("text").
+			WithOutputFile("/var/log/app.log.gz").
+			WithCompression().
+			WithSplitOutput(errOut).
+			With(func(config *LoggingConfig) { config.MaxFields = 20 }).
+			Build()
+		assert.NoError(t, err)
+		assert.Equal(t, "DEBUG", config.LogLevel)
+		assert.Equal(t, []string{"production"}, config.BugsnagNotifyReleaseStages)
+		assert.True(t, config.CompressOutput)
+		assert.Same(t, errOut, config.ErrorOutput)
+		assert.Equal(t, 20, config.MaxFields)
+	})
+	t.Run("empty config is valid", func(t *testing.T) {
+		_, err := NewConfigBuilder().Build()
+		assert.NoError(t, err)
+	})
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewConfigBuilder().
+			WithLevel("VERBOSE").
+			WithFormat("yaml").
+			WithCompression().
+			WithAsync(0, func(int) {}).
+			WithSampleRate(2).
+			Build()
+		assert.ErrorContains(t, err, `unknown LogLevel "VERBOSE"`)
+		assert.ErrorContains(t, err, `unknown Format "yaml"`)
+		assert.ErrorContains(t, err, "CompressOutput requires an OutputFile")
+		assert.ErrorContains(t, err, "OnDrop requires an AsyncBufferSize")
+		assert.ErrorContains(t, err, "SampleRate 2 is outside [0, 1]")
+	})
+	t.Run("negative limits", func(t *testing.T) {
+		_, err := NewConfigBuilder().With(func(config *LoggingConfig) {
+			config.AsyncBufferSize = -1
+			config.MaxFields = -1
+			config.MaxMessageLength = -1
+			config.MaxFieldValueLength = -1
+		}).Build()
+		assert.ErrorContains(t, err, "AsyncBufferSize must not be negative")
+		assert.ErrorContains(t, err, "MaxFields must not be negative")
+		assert.ErrorContains(t, err, "MaxMessageLength must not be negative")
+		assert.ErrorContains(t, err, "MaxFieldValueLength must not be negative")
+	})
+	t.Run("incomplete CloudWatch config", func(t *testing.T) {
+		_, err := NewConfigBuilder().With(func(config *LoggingConfig) { config.CloudWatchGroup = "app" }).Build()
+		assert.ErrorContains(t, err, "CloudWatchGroup requires a CloudWatchStream and NewCloudWatchClient")
+
+		_, err = NewConfigBuilder().With(func(config *LoggingConfig) { config.CloudWatchStream = "web-1" }).Build()
+		assert.ErrorContains(t, err, "CloudWatchStream and NewCloudWatchClient require a CloudWatchGroup")
+	})
+	t.Run("encoder and format conflict", func(t *testing.T) {
+		_, err := NewConfigBuilder().WithFormat("json").WithEncoder(BinaryEncoder{}).Build()
+		assert.ErrorContains(t, err, "Format and Encoder are mutually exclusive")
+	})
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ContextExtractor adds fields found in ctx to the entry and returns it.
+type ContextExtractor func(ctx context.Context, e *Entry) *Entry
+
+// registeredExtractor gives each registration an identity, so the same
+// function registered twice is removed once at a time.
+type registeredExtractor struct {
+	extract ContextExtractor
+}
+
+var (
+	contextExtractorsMu sync.RWMutex
+	// contextExtractors is never modified in place, since WithContext
+	// iterates over it without holding the lock.
+	contextExtractors = []*registeredExtractor{
+		{withDDTrace},
+		{withDeadline},
+		{withExperiments},
+	}
+)
+
+// RegisterContextExtractor adds an extractor that is run by WithContext.
+// Extractors run in registration order after the built-in ones. The
+// returned function removes the extractor again, e.g. in test cleanup.
+func RegisterContextExtractor(extractor ContextExtractor) (remove func()) {
+	registered := &registeredExtractor{extractor}
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+	contextExtractors = append(contextExtractors[:len(contextExtractors):len(contextExtractors)], registered)
+	return func() {
+		contextExtractorsMu.Lock()
+		defer contextExtractorsMu.Unlock()
+		extractors := make([]*registeredExtractor, 0, len(contextExtractors))
+		for _, other := range contextExtractors {
+			if other != registered {
+				extractors = append(extractors, other)
+			}
+		}
+		contextExtractors = extractors
+	}
+}
+
+func (l *Logger) WithContext(ctx context.Context) *Entry {
+	return l.NewEntry().WithContext(ctx)
+}
+
+// EntryFromContext returns a new entry enriched by every registered
+// ContextExtractor, including trace correlation. It replaces the common
+// Log.NewEntry().WithDDTrace(ctx).
+func (l *Logger) EntryFromContext(ctx context.Context) *Entry {
+	return l.WithContext(ctx)
+}
+
+// WithContext attaches ctx to the entry and runs every registered
+// ContextExtractor on it.
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	contextExtractorsMu.RLock()
+	extractors := contextExtractors
+	contextExtractorsMu.RUnlock()
+
+	if depth := stackDepth(e.Context); depth > 0 {
+		ctx = context.WithValue(ctx, stackDepthKey{}, depth)
+	}
+	entry := &Entry{e.Entry.WithContext(ctx)}
+	for _, registered := range extractors {
+		entry = registered.extract(ctx, entry)
+	}
+	return entry
+}
+
+func withDDTrace(ctx context.Context, e *Entry) *Entry {
+	return e.WithDDTrace(ctx)
+}
+
+func withDeadline(ctx context.Context, e *Entry) *Entry {
+	if deadline, ok := ctx.Deadline(); ok {
+		return e.WithField(FieldDeadline, deadline.Format(time.RFC3339Nano))
+	}
+	return e
+}
+
+// WithContextError records why ctx is done, if it is: context_error is
+// "canceled" or "deadline_exceeded" and context_cause carries the cause set
+// through context.WithCancelCause and friends.
+func (e *Entry) WithContextError(ctx context.Context) *Entry {
+	err := ctx.Err()
+	if err == nil {
+		return e
+	}
+	reason := "canceled"
+	if errors.Is(err, context.DeadlineExceeded) {
+		reason = "deadline_exceeded"
+	}
+	entry := e.WithField(FieldContextError, reason)
+	if cause := context.Cause(ctx); cause != nil && cause != err {
+		entry = entry.WithField(FieldContextCause, cause.Error())
+	}
+	return entry
+}
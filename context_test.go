@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type testContextKey string
+
+func TestWithContext(t *testing.T) {
+	defer Log.Snapshot()()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	t.Cleanup(RegisterContextExtractor(func(ctx context.Context, e *Entry) *Entry {
+		id, _ := ctx.Value(testContextKey("request_id")).(string)
+		return e.WithStringFieldIgnoreEmpty("request_id", id)
+	}))
+	t.Cleanup(RegisterContextExtractor(func(ctx context.Context, e *Entry) *Entry {
+		tenant, _ := ctx.Value(testContextKey("tenant")).(string)
+		return e.WithStringFieldIgnoreEmpty("tenant", tenant)
+	}))
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	deadline := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = tracer.ContextWithSpan(ctx, span)
+	ctx = context.WithValue(ctx, testContextKey("request_id"), "req-1")
+	ctx = context.WithValue(ctx, testContextKey("tenant"), "acme")
+
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	Log.WithContext(ctx).Info("handled")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.trace_id":%d`, span.Context().TraceID()))
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.span_id":%d`, span.Context().SpanID()))
+	assert.Contains(t, logFileContent, `"deadline":"2030-01-01T00:00:00Z"`)
+	assert.Contains(t, logFileContent, `"request_id":"req-1"`)
+	assert.Contains(t, logFileContent, `"tenant":"acme"`)
+}
+
+func TestRegisterContextExtractorRemove(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	extractor := func(ctx context.Context, e *Entry) *Entry {
+		return e.WithField("tenant", "acme")
+	}
+	removeFirst := RegisterContextExtractor(extractor)
+	removeSecond := RegisterContextExtractor(extractor)
+	defer removeSecond()
+
+	removeFirst()
+	assert.Equal(t, "acme", logger.WithContext(context.Background()).Data["tenant"], "only one registration is removed")
+	removeSecond()
+	assert.NotContains(t, logger.WithContext(context.Background()).Data, "tenant")
+}
+
+func TestEntryFromContext(t *testing.T) {
+	defer Log.Snapshot()()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	ctx := tracer.ContextWithSpan(context.Background(), span)
+
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	Log.EntryFromContext(ctx).Info("handled")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.trace_id":%d`, span.Context().TraceID()))
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.span_id":%d`, span.Context().SpanID()))
+}
+
+func TestWithContextError(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	causeCanceled, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(errors.New("client went away"))
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want logrus.Fields
+	}{
+		{"live", context.Background(), logrus.Fields{}},
+		{"canceled", canceled, logrus.Fields{"context_error": "canceled"}},
+		{"canceled with cause", causeCanceled, logrus.Fields{"context_error": "canceled", "context_cause": "client went away"}},
+		{"deadline exceeded", expired, logrus.Fields{"context_error": "deadline_exceeded"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, logger.NewEntry().WithContextError(tt.ctx).Data)
+		})
+	}
+}
+
+// unnamedSpan hides the operation name of the span it wraps, like tracers
+// that do not expose one.
+type unnamedSpan struct {
+	ddtrace.Span
+}
+
+func TestWithDDTraceSpanName(t *testing.T) {
+	defer Log.Snapshot()()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	Log.Logger.Level = logrus.DebugLevel
+
+	span := tracer.StartSpan("db.query")
+	defer span.Finish()
+
+	t.Run("operation name is added when exposed", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithDDTrace(tracer.ContextWithSpan(context.Background(), span)).Info("queried")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"dd.name":"db.query"`)
+	})
+	t.Run("tracers without operation names are supported", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		ctx := tracer.ContextWithSpan(context.Background(), unnamedSpan{span})
+		Log.NewEntry().WithDDTrace(ctx).Info("queried")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.span_id":%d`, span.Context().SpanID()))
+		assert.NotContains(t, logFileContent, "dd.name")
+	})
+}
+
+func TestWithSpanName(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	assert.Equal(t, "db.query", logger.NewEntry().WithSpanName("db.query").Data["dd.name"])
+	assert.NotContains(t, logger.NewEntry().WithSpanName("").Data, "dd.name")
+}
+
+func TestWithSpanLink(t *testing.T) {
+	defer Log.Snapshot()()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	Log.Logger.Level = logrus.DebugLevel
+
+	parent := tracer.StartSpan("fan_out")
+	defer parent.Finish()
+	child := tracer.StartSpan("worker")
+	defer child.Finish()
+
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+
+	Log.WithContext(tracer.ContextWithSpan(context.Background(), child)).
+		WithSpanLink(parent.Context().TraceID(), parent.Context().SpanID()).
+		Info("processed shard")
+	logFileContent := logFile.getLogFileContent(t)
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.span_id":%d`, child.Context().SpanID()))
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.linked_trace_id":%d`, parent.Context().TraceID()))
+	assert.Contains(t, logFileContent, fmt.Sprintf(`"dd.linked_span_id":%d`, parent.Context().SpanID()))
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// warnDeprecated is set by Init outside production.
+	warnDeprecated   atomic.Bool
+	deprecatedWarned sync.Map
+)
+
+// deprecated logs a one-time Debug entry naming a deprecated helper and its
+// first caller, so that teams notice it before it is removed. It is silent
+// in production.
+func deprecated(name string) {
+	logger := Default()
+	if !warnDeprecated.Load() || logger == nil {
+		return
+	}
+	if _, warned := deprecatedWarned.LoadOrStore(name, true); warned {
+		return
+	}
+	logger.NewEntry().
+		WithField(FieldDeprecated, name).
+		WithField(FieldCaller, callerOutsideLogging()).
+		Debugf("%s is deprecated", name)
+}
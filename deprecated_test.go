@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecatedWarnsOnce(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+	deprecatedWarned.Clear()
+
+	t.Run("outside production", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithRutilus().Info("first")
+		Log.NewEntry().WithRutilus().Info("second")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Equal(t, 1, strings.Count(logFileContent, `"deprecated":"WithRutilus"`))
+		assert.Contains(t, logFileContent, `"message":"WithRutilus is deprecated"`)
+		assert.Contains(t, logFileContent, "deprecated_test.go")
+	})
+	t.Run("silent in production", func(t *testing.T) {
+		warnDeprecated.Store(false)
+		defer warnDeprecated.Store(true)
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithHTTPResponseCode(200).Info("handled")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.NotContains(t, logFileContent, "deprecated")
+	})
+}
@@ -0,0 +1,240 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Record is the encoder-neutral form of a log entry.
+type Record struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Encoder serializes records, replacing the JSON formatter when set as
+// LoggingConfig.Encoder. Each call returns the bytes for one record,
+// including any framing needed to split a stream back into records.
+type Encoder interface {
+	Encode(r Record) ([]byte, error)
+}
+
+// encoderFormatter adapts an Encoder to logrus.Formatter.
+type encoderFormatter struct {
+	encoder Encoder
+}
+
+func (f *encoderFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return f.encoder.Encode(Record{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  entry.Data,
+	})
+}
+
+const (
+	binaryTypeNil byte = iota
+	binaryTypeString
+	binaryTypeInt
+	binaryTypeUint
+	binaryTypeFloat
+	binaryTypeBool
+)
+
+// BinaryEncoder is a reference Encoder producing length-prefixed binary
+// records, for pipelines where JSON encoding is too costly. Field values
+// that are not strings, integers, floats, bools or nil are stored as their
+// fmt %v representation; errors are stored as their message.
+type BinaryEncoder struct{}
+
+func (BinaryEncoder) Encode(r Record) ([]byte, error) {
+	scratch := getScratch()
+	defer putScratch(scratch)
+	body := binary.AppendVarint(*scratch, r.Time.UnixNano())
+	body = append(body, byte(r.Level))
+	body = appendBinaryString(body, r.Message)
+
+	keys := make([]string, 0, len(r.Fields))
+	for key := range r.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	body = binary.AppendUvarint(body, uint64(len(keys)))
+	for _, key := range keys {
+		body = appendBinaryString(body, key)
+		body = appendBinaryValue(body, r.Fields[key])
+	}
+
+	*scratch = body
+
+	out := make([]byte, 0, binary.MaxVarintLen64+len(body))
+	out = binary.AppendUvarint(out, uint64(len(body)))
+	return append(out, body...), nil
+}
+
+func appendBinaryString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendBinaryValue(b []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(b, binaryTypeNil)
+	case string:
+		return appendBinaryString(append(b, binaryTypeString), v)
+	case int:
+		return binary.AppendVarint(append(b, binaryTypeInt), int64(v))
+	case int8:
+		return binary.AppendVarint(append(b, binaryTypeInt), int64(v))
+	case int16:
+		return binary.AppendVarint(append(b, binaryTypeInt), int64(v))
+	case int32:
+		return binary.AppendVarint(append(b, binaryTypeInt), int64(v))
+	case int64:
+		return binary.AppendVarint(append(b, binaryTypeInt), v)
+	case uint:
+		return binary.AppendUvarint(append(b, binaryTypeUint), uint64(v))
+	case uint8:
+		return binary.AppendUvarint(append(b, binaryTypeUint), uint64(v))
+	case uint16:
+		return binary.AppendUvarint(append(b, binaryTypeUint), uint64(v))
+	case uint32:
+		return binary.AppendUvarint(append(b, binaryTypeUint), uint64(v))
+	case uint64:
+		return binary.AppendUvarint(append(b, binaryTypeUint), v)
+	case float32:
+		return binary.LittleEndian.AppendUint64(append(b, binaryTypeFloat), math.Float64bits(float64(v)))
+	case float64:
+		return binary.LittleEndian.AppendUint64(append(b, binaryTypeFloat), math.Float64bits(v))
+	case bool:
+		if v {
+			return append(b, binaryTypeBool, 1)
+		}
+		return append(b, binaryTypeBool, 0)
+	case error:
+		return appendBinaryString(append(b, binaryTypeString), v.Error())
+	default:
+		return appendBinaryString(append(b, binaryTypeString), fmt.Sprintf("%v", v))
+	}
+}
+
+// BinaryDecoder reads records written by BinaryEncoder from a stream.
+type BinaryDecoder struct {
+	r *bufio.Reader
+}
+
+func NewBinaryDecoder(r io.Reader) *BinaryDecoder {
+	return &BinaryDecoder{r: bufio.NewReader(r)}
+}
+
+var errCorruptBinaryRecord = errors.New("corrupt binary log record")
+
+// maxBinaryRecordSize bounds the size prefix Decode trusts, so a corrupt
+// prefix fails the record instead of allocating gigabytes.
+const maxBinaryRecordSize = 64 << 20
+
+// Decode reads the next record. It returns io.EOF when the stream ends
+// cleanly between records.
+func (d *BinaryDecoder) Decode() (Record, error) {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return Record{}, err
+	}
+	if size > maxBinaryRecordSize {
+		return Record{}, errCorruptBinaryRecord
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(d.r, body); err != nil {
+		return Record{}, err
+	}
+	return decodeBinaryBody(body)
+}
+
+func decodeBinaryBody(body []byte) (Record, error) {
+	var r Record
+	nanos, n := binary.Varint(body)
+	if n <= 0 || n >= len(body) {
+		return r, errCorruptBinaryRecord
+	}
+	r.Time = time.Unix(0, nanos)
+	r.Level = logrus.Level(body[n])
+	body = body[n+1:]
+
+	var err error
+	if r.Message, body, err = readBinaryString(body); err != nil {
+		return r, err
+	}
+	count, n := binary.Uvarint(body)
+	// Every field takes at least two bytes, for its key size and value tag.
+	if n <= 0 || count > uint64(len(body)-n)/2 {
+		return r, errCorruptBinaryRecord
+	}
+	body = body[n:]
+
+	r.Fields = make(map[string]interface{}, count)
+	for i := uint64(0); i < count; i++ {
+		var key string
+		if key, body, err = readBinaryString(body); err != nil {
+			return r, err
+		}
+		if r.Fields[key], body, err = readBinaryValue(body); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func readBinaryString(b []byte) (string, []byte, error) {
+	size, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < size {
+		return "", nil, errCorruptBinaryRecord
+	}
+	return string(b[n : n+int(size)]), b[n+int(size):], nil
+}
+
+func readBinaryValue(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errCorruptBinaryRecord
+	}
+	tag, b := b[0], b[1:]
+	switch tag {
+	case binaryTypeNil:
+		return nil, b, nil
+	case binaryTypeString:
+		return readBinaryString(b)
+	case binaryTypeInt:
+		v, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, nil, errCorruptBinaryRecord
+		}
+		return v, b[n:], nil
+	case binaryTypeUint:
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, nil, errCorruptBinaryRecord
+		}
+		return v, b[n:], nil
+	case binaryTypeFloat:
+		if len(b) < 8 {
+			return nil, nil, errCorruptBinaryRecord
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), b[8:], nil
+	case binaryTypeBool:
+		if len(b) < 1 {
+			return nil, nil, errCorruptBinaryRecord
+		}
+		return b[0] == 1, b[1:], nil
+	}
+	return nil, nil, errCorruptBinaryRecord
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryEncoderRoundTrip(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{Encoder: BinaryEncoder{}})
+	logger.Out = out
+
+	logger.NewEntry().
+		WithUser(10).
+		WithChannel("fcm").
+		WithPercentage("progress", 0.25).
+		WithField("retry", true).
+		WithField("attempt", -2).
+		WithField("missing", nil).
+		Warn("first")
+	logger.WithField("count", 3).Info("second")
+
+	decoder := NewBinaryDecoder(out)
+	first, err := decoder.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.WarnLevel, first.Level)
+	assert.Equal(t, "first", first.Message)
+	assert.WithinDuration(t, time.Now(), first.Time, time.Minute)
+	assert.Equal(t, map[string]interface{}{
+		"usr.id":       uint64(10),
+		"channel":      "fcm",
+		"progress_pct": 25.0,
+		"retry":        true,
+		"attempt":      int64(-2),
+		"missing":      nil,
+	}, first.Fields)
+
+	second, err := decoder.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.InfoLevel, second.Level)
+	assert.Equal(t, "second", second.Message)
+	assert.Equal(t, map[string]interface{}{"count": int64(3)}, second.Fields)
+
+	_, err = decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinaryEncoderStringifiesOtherValues(t *testing.T) {
+	data, err := BinaryEncoder{}.Encode(Record{Fields: map[string]interface{}{
+		"error.message": errors.New("boom"),
+		"tags":          []string{"a", "b"},
+	}})
+	assert.NoError(t, err)
+
+	record, err := NewBinaryDecoder(bytes.NewReader(data)).Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, "boom", record.Fields["error.message"])
+	assert.Equal(t, "[a b]", record.Fields["tags"])
+}
+
+func TestBinaryDecoderCorruptSize(t *testing.T) {
+	t.Run("record", func(t *testing.T) {
+		data := binary.AppendUvarint(nil, 1<<40)
+		_, err := NewBinaryDecoder(bytes.NewReader(data)).Decode()
+		assert.ErrorIs(t, err, errCorruptBinaryRecord)
+	})
+	t.Run("field count", func(t *testing.T) {
+		body := binary.AppendVarint(nil, 0)
+		body = append(body, byte(logrus.InfoLevel), 0)
+		body = binary.AppendUvarint(body, 1<<40)
+		data := append(binary.AppendUvarint(nil, uint64(len(body))), body...)
+		_, err := NewBinaryDecoder(bytes.NewReader(data)).Decode()
+		assert.ErrorIs(t, err, errCorruptBinaryRecord)
+	})
+}
+
+func benchmarkFormatter(b *testing.B, config LoggingConfig) {
+	logger := new(false, config)
+	entry := logger.NewEntry().
+		WithUser(10).
+		WithChannel("fcm").
+		WithEvent("catch_created,1,2").
+		WithDuration(time.Second).Entry
+	entry.Level = logrus.InfoLevel
+	entry.Message = "benchmark"
+	entry.Time = time.Now()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := logger.Formatter.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	benchmarkFormatter(b, LoggingConfig{})
+}
+
+func BenchmarkBinaryEncoder(b *testing.B) {
+	benchmarkFormatter(b, LoggingConfig{Encoder: BinaryEncoder{}})
+}
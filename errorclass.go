@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"errors"
+	stdlog "log"
+	"reflect"
+	"sync"
+
+	bugsnag_errors "github.com/bugsnag/bugsnag-go/v2/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const maxErrorClassDepth = 10
+
+// wrapperErrorClasses only add context to the error they wrap and never make
+// a useful class on their own.
+var wrapperErrorClasses = map[string]bool{
+	"*fmt.wrapError":         true,
+	"*errors.withStack":      true, // github.com/pkg/errors
+	"*errors.withMessage":    true, // github.com/pkg/errors
+	"*logging.scrubbedError": true,
+}
+
+// genericErrorClasses are plain message errors, used only when nothing more
+// specific is found in the chain.
+var genericErrorClasses = map[string]bool{
+	"*errors.errorString": true,
+	"*errors.fundamental": true, // github.com/pkg/errors
+}
+
+// DefaultErrorClass walks the Unwrap chain of err and returns the type of
+// the deepest error that is neither a wrapper (fmt.Errorf with %w,
+// github.com/pkg/errors) nor a plain message error. If there is none, the
+// deepest non-wrapper error is used.
+func DefaultErrorClass(err error) string {
+	var meaningful, fallback string
+	for depth := 0; err != nil; depth++ {
+		if depth > maxErrorClassDepth {
+			stdlog.Printf("Failed to unwrap error %s %+v", fallback, err)
+			break
+		}
+		// bugsnag's Unwrap skips the error it wraps.
+		if bugsnagErr, ok := err.(*bugsnag_errors.Error); ok {
+			err = bugsnagErr.Err
+			continue
+		}
+		errClass := reflect.TypeOf(err).String()
+		switch {
+		case wrapperErrorClasses[errClass]:
+		case genericErrorClasses[errClass]:
+			fallback = errClass
+		default:
+			meaningful = errClass
+			fallback = errClass
+		}
+		err = errors.Unwrap(err)
+	}
+	if meaningful != "" {
+		return meaningful
+	}
+	return fallback
+}
+
+// errorClassOf returns the class of err as reported to Bugsnag, using the
+// ErrorClass configured by Init, if any.
+func errorClassOf(err error) string {
+	if errorClass := errorClassFunc.Load(); errorClass != nil {
+		return (*errorClass)(err)
+	}
+	return DefaultErrorClass(err)
+}
+
+// errorStatsHook counts entries carrying an error by error class.
+type errorStatsHook struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func (h *errorStatsHook) Fire(entry *logrus.Entry) error {
+	err, ok := entry.Data[logrus.ErrorKey].(error)
+	if !ok {
+		return nil
+	}
+	errClass := errorClassOf(err)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[errClass]++
+	return nil
+}
+
+func (h *errorStatsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// ErrorStats returns how many errors of each class have been logged, or nil
+// unless LoggingConfig.TrackErrorStats is set.
+func (l *Logger) ErrorStats() map[string]uint64 {
+	h := l.errorStats
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := make(map[string]uint64, len(h.counts))
+	for errClass, count := range h.counts {
+		stats[errClass] = count
+	}
+	return stats
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	bugsnag_errors "github.com/bugsnag/bugsnag-go/v2/errors"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type notFoundError struct{ err error }
+
+func (e *notFoundError) Error() string { return "not found: " + e.err.Error() }
+func (e *notFoundError) Unwrap() error { return e.err }
+
+type queryError struct{ err error }
+
+func (e *queryError) Error() string { return "query: " + e.err.Error() }
+func (e *queryError) Unwrap() error { return e.err }
+
+func TestDefaultErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"plain error", errors.New("boom"), "*errors.errorString"},
+		{"fmt wrapped", fmt.Errorf("ctx: %w", errors.New("boom")), "*errors.errorString"},
+		{"fmt wrapped custom", fmt.Errorf("ctx: %w", &notFoundError{errors.New("boom")}), "*logging.notFoundError"},
+		{"pkg/errors wrapped", pkgerrors.Wrap(&notFoundError{errors.New("boom")}, "ctx"), "*logging.notFoundError"},
+		{"pkg/errors plain", pkgerrors.Wrap(pkgerrors.New("boom"), "ctx"), "*errors.fundamental"},
+		{"custom chain", &queryError{fmt.Errorf("ctx: %w", &notFoundError{errors.New("boom")})}, "*logging.notFoundError"},
+		{"bugsnag wrapped", bugsnag_errors.New(&notFoundError{errors.New("boom")}, 0), "*logging.notFoundError"},
+		{"nil", nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, DefaultErrorClass(test.err))
+		})
+	}
+}
+
+func TestErrorStats(t *testing.T) {
+	logger := new(false, LoggingConfig{TrackErrorStats: true})
+	logger.Out = io.Discard
+
+	logger.WithError(errors.New("boom")).Error("plain")
+	logger.WithError(fmt.Errorf("lookup: %w", &notFoundError{errors.New("user")})).Warn("wrapped")
+	logger.WithError(&notFoundError{errors.New("catch")}).Error("direct")
+	logger.WithError(pkgerrors.Wrap(&queryError{errors.New("timeout")}, "list")).Info("pkg wrapped")
+	logger.Error("no error")
+
+	assert.Equal(t, map[string]uint64{
+		"*errors.errorString":    1,
+		"*logging.notFoundError": 2,
+		"*logging.queryError":    1,
+	}, logger.ErrorStats())
+}
+
+func TestErrorStatsDisabled(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.WithError(errors.New("boom")).Error("not tracked")
+	assert.Nil(t, logger.ErrorStats())
+}
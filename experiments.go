@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"context"
+	"sync"
+)
+
+// ExperimentsAccessor returns the experiment assignments carried by ctx,
+// keyed by experiment name, e.g. {"new_feed": "treatment"}. It lets the
+// experimentation SDK be plugged in without this package depending on it.
+type ExperimentsAccessor func(ctx context.Context) map[string]string
+
+var (
+	experimentsAccessorMu sync.RWMutex
+	experimentsAccessor   ExperimentsAccessor
+)
+
+// SetExperimentsAccessor sets the accessor WithExperiments and WithContext
+// read experiment assignments with. Nil disables them.
+func SetExperimentsAccessor(accessor ExperimentsAccessor) {
+	experimentsAccessorMu.Lock()
+	defer experimentsAccessorMu.Unlock()
+	experimentsAccessor = accessor
+}
+
+// WithExperiments adds the experiment assignments in ctx under experiments.
+// It is a noop without an accessor or assignments.
+func (e *Entry) WithExperiments(ctx context.Context) *Entry {
+	experimentsAccessorMu.RLock()
+	accessor := experimentsAccessor
+	experimentsAccessorMu.RUnlock()
+	if accessor == nil {
+		return e
+	}
+	assignments := accessor(ctx)
+	if len(assignments) == 0 {
+		return e
+	}
+	experiments := make(map[string]string, len(assignments))
+	for name, group := range assignments {
+		experiments[name] = group
+	}
+	return e.WithField(FieldExperiments, experiments)
+}
+
+func withExperiments(ctx context.Context, e *Entry) *Entry {
+	return e.WithExperiments(ctx)
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type experimentsKey struct{}
+
+func TestWithExperiments(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+	ctx := context.WithValue(context.Background(), experimentsKey{}, map[string]string{"new_feed": "treatment"})
+
+	t.Run("noop without an accessor", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithExperiments(ctx).Info("feed loaded")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.NotContains(t, logFileContent, "experiments")
+	})
+
+	SetExperimentsAccessor(func(ctx context.Context) map[string]string {
+		assignments, _ := ctx.Value(experimentsKey{}).(map[string]string)
+		return assignments
+	})
+	defer SetExperimentsAccessor(nil)
+
+	t.Run("assignments are read through the accessor", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().WithExperiments(ctx).Info("feed loaded")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"experiments":{"new_feed":"treatment"}`)
+	})
+	t.Run("WithContext adds them too", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.WithContext(ctx).Info("feed loaded")
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"experiments":{"new_feed":"treatment"}`)
+	})
+}
@@ -0,0 +1,126 @@
+package logging
+
+// Field names set by the helpers in this package, for downstream code that
+// queries logs or builds custom fields.
+const (
+	FieldLoggerName = "logger_name"
+	FieldComponent  = "component"
+	FieldService    = "service"
+
+	FieldHTTPMethod      = "http.method"
+	FieldHTTPHost        = "http.host"
+	FieldHTTPRoute       = "http.route"
+	FieldHTTPURL         = "http.url"
+	FieldHTTPStatusCode  = "http.status_code"
+	FieldHTTPStatusClass = "http.status_class"
+
+	FieldRateLimitLimit     = "ratelimit_limit"
+	FieldRateLimitRemaining = "ratelimit_remaining"
+	FieldRateLimitReset     = "ratelimit_reset"
+
+	FieldUserID        = "usr.id"
+	FieldSessionID     = "session_id"
+	FieldEvent         = "event"
+	FieldEventName     = "event_name"
+	FieldParentEventID = "parent_event_id"
+	FieldObjectID      = "object_id"
+	FieldSubjectID     = "subject_id"
+
+	FieldSpecies      = "species"
+	FieldWaterBody    = "water_body"
+	FieldMethod       = "method"
+	FieldRelation     = "relation"
+	FieldResourceType = "resource_type"
+	FieldResourceID   = "resource_id"
+
+	FieldMediaID        = "media_id"
+	FieldMediaType      = "media_type"
+	FieldMediaSizeBytes = "media_size_bytes"
+
+	FieldDBOperation    = "db_operation"
+	FieldDBTable        = "db_table"
+	FieldDBRowsAffected = "db_rows_affected"
+	FieldSQLQuery       = "sql_query"
+
+	FieldCloudRegion           = "cloud_region"
+	FieldCloudAvailabilityZone = "cloud_az"
+	FieldGitSHA                = "git_sha"
+	FieldBuildTime             = "build_time"
+	FieldHostname              = "hostname"
+	FieldPID                   = "pid"
+	FieldLogID                 = "log_id"
+	FieldSampled               = "sampled"
+	FieldSampleRate            = "sample_rate"
+	FieldGoroutineID           = "goroutine_id"
+
+	FieldCacheName = "cache_name"
+	FieldCacheHit  = "cache_hit"
+
+	FieldIdempotencyKey = "idempotency_key"
+	FieldNSQMessageID   = "nsq_message_id"
+	FieldKafkaTopic     = "kafka_topic"
+	FieldKafkaPartition = "kafka_partition"
+	FieldKafkaOffset    = "kafka_offset"
+	FieldKafkaKey       = "kafka_key"
+	FieldMessageAge     = "message_age_ms"
+	FieldQueueDepth     = "queue_depth"
+	FieldActiveWorkers  = "active_workers"
+	FieldGoroutines     = "goroutines"
+	FieldHeapAllocBytes = "heap_alloc_bytes"
+	FieldNumGC          = "num_gc"
+
+	FieldIntervalFrom       = "interval_from"
+	FieldIntervalTo         = "interval_to"
+	FieldIntervalDurationMS = "interval_duration_ms"
+
+	FieldAttempt      = "attempt"
+	FieldMaxAttempts  = "max_attempts"
+	FieldDuration     = "duration"
+	FieldE2EDuration  = "e2e_duration"
+	FieldTimings      = "timings_ms"
+	FieldOutcome      = "outcome"
+	FieldChanges      = "changes"
+	FieldChannel      = "channel"
+	FieldErrors       = "errors"
+	FieldDeadline     = "deadline"
+	FieldContextError = "context_error"
+	FieldContextCause = "context_cause"
+	FieldStack        = "stack"
+	FieldThrottleKey  = "throttle_key"
+	FieldExperiments  = "experiments"
+
+	FieldDeliveryProvider  = "delivery_provider"
+	FieldDeliveryStatus    = "delivery_status"
+	FieldDeliveryMessageID = "delivery_message_id"
+
+	FieldClientPlatform = "client_platform"
+	FieldClientVersion  = "client_version"
+
+	FieldDDTraceID = "dd.trace_id"
+	FieldDDSpanID  = "dd.span_id"
+	FieldDDName    = "dd.name"
+
+	FieldDDLinkedTraceID = "dd.linked_trace_id"
+	FieldDDLinkedSpanID  = "dd.linked_span_id"
+
+	// Set on the entries this package logs about itself.
+	FieldCaller         = "caller"
+	FieldDeprecated     = "deprecated"
+	FieldFieldCount     = "field_count"
+	FieldMaxFields      = "max_fields"
+	FieldCounts         = "counts"
+	FieldIgnoredKey     = "ignored_key"
+	FieldReplayedLevel  = "replayed_level"
+	FieldFormatterError = "formatter_error"
+	FieldTimeout        = "timeout"
+	FieldCooldown       = "cooldown"
+
+	FieldLogLevel       = "log_level"
+	FieldLogFormat      = "log_format"
+	FieldLogOutput      = "log_output"
+	FieldLogAsync       = "log_async"
+	FieldLogSampleRate  = "log_sample_rate"
+	FieldBugsnagEnabled = "bugsnag_enabled"
+	FieldEnvironment    = "environment"
+	FieldAppVersion     = "app_version"
+)
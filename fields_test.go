@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelpersUseFieldConstants(t *testing.T) {
+	entry := Log.NewEntry().
+		Named("worker").
+		WithComponent("indexer").
+		WithHTTPMethod("GET").
+		WithUser(1).
+		WithSessionID("s").
+		WithEvent("caught,2,3").
+		WithCatch("pike", "lake", "fly").
+		WithResource("catch", "4").
+		WithDBOperation("update", "catches", 1).
+		WithRegion("eu-west-1").
+		WithAvailabilityZone("eu-west-1a").
+		WithIdempotencyKey("key").
+		WithWorkerStats(1, 2).
+		WithDuration(time.Second).
+		WithE2EDuration(time.Second).
+		WithOutcome("success").
+		WithChannel("fcm").
+		WithClient("ios", "1.0")
+
+	for _, key := range []string{
+		FieldLoggerName, FieldComponent, FieldHTTPMethod, FieldUserID,
+		FieldSessionID, FieldEventName, FieldObjectID, FieldSubjectID,
+		FieldSpecies, FieldWaterBody, FieldMethod, FieldResourceType,
+		FieldResourceID, FieldDBOperation, FieldDBTable, FieldDBRowsAffected,
+		FieldCloudRegion, FieldCloudAvailabilityZone, FieldIdempotencyKey,
+		FieldQueueDepth, FieldActiveWorkers, FieldDuration, FieldE2EDuration,
+		FieldOutcome, FieldChannel, FieldClientPlatform, FieldClientVersion,
+	} {
+		assert.Contains(t, entry.Data, key)
+	}
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fallbackFormatter formats with primary and, should primary fail, falls
+// back to a plain key=value line carrying the formatter error, so a line is
+// never dropped because of a broken formatter.
+type fallbackFormatter struct {
+	primary  logrus.Formatter
+	fallback logrus.Formatter
+	stats    *loggerStats
+}
+
+func newFallbackFormatter(primary logrus.Formatter, stats *loggerStats) *fallbackFormatter {
+	return &fallbackFormatter{
+		primary: primary,
+		fallback: &logrus.TextFormatter{
+			DisableColors:   true,
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339Nano,
+		},
+		stats: stats,
+	}
+}
+
+func (f *fallbackFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	serialized, err := f.primary.Format(entry)
+	if err == nil {
+		return serialized, nil
+	}
+	f.stats.formatErrors.Add(1)
+
+	fallback := entry.Dup()
+	fallback.Level = entry.Level
+	fallback.Message = entry.Message
+	fallback.Caller = entry.Caller
+	fallback.Data[FieldFormatterError] = err.Error()
+	return f.fallback.Format(fallback)
+}
+
+// FormatErrorCount returns how many entries had to be written with the
+// fallback formatter because the configured one failed.
+func (l *Logger) FormatErrorCount() uint64 {
+	return l.stats.formatErrors.Load()
+}
+
+// belowErrorFormatter leaves Error, Fatal and Panic entries out of the
+// output by formatting them as an empty line. With SplitOutputByLevel they
+// are written to the error output by a formattedOutputHook instead.
+type belowErrorFormatter struct {
+	formatter logrus.Formatter
+}
+
+func (f *belowErrorFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level <= logrus.ErrorLevel {
+		return nil, nil
+	}
+	return f.formatter.Format(entry)
+}
+
+// truncatingFormatter shortens messages longer than max bytes, leaving the
+// fields untouched.
+type truncatingFormatter struct {
+	formatter logrus.Formatter
+	max       int
+}
+
+func (f *truncatingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if len(entry.Message) <= f.max {
+		return f.formatter.Format(entry)
+	}
+	truncated := *entry
+	truncated.Message = truncateString(entry.Message, f.max)
+	return f.formatter.Format(&truncated)
+}
+
+// truncateString cuts s to at most max bytes, backing off to a rune
+// boundary, and notes how many bytes were cut.
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…[truncated %d bytes]", s[:cut], len(s)-cut)
+}
+
+// flatteningFormatter lifts the entries of nested maps into top-level
+// dotted keys, e.g. {"timings_ms":{"auth":3}} becomes {"timings_ms.auth":3},
+// for backends that do not index nested objects.
+type flatteningFormatter struct {
+	formatter logrus.Formatter
+}
+
+func (f *flatteningFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	flat := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		flattenField(flat, key, value)
+	}
+	flattened := *entry
+	flattened.Data = flat
+	return f.formatter.Format(&flattened)
+}
+
+func flattenField(flat logrus.Fields, key string, value interface{}) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String || v.Len() == 0 {
+		flat[key] = value
+		return
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		flattenField(flat, key+"."+iter.Key().String(), iter.Value().Interface())
+	}
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(Record) ([]byte, error) {
+	return nil, errors.New("encoder broke")
+}
+
+func TestFallbackFormatter(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{Encoder: failingEncoder{}})
+	logger.Out = logFile.in
+	logger.Level = logrus.InfoLevel
+
+	logger.WithField("usr.id", 10).Info("still logged")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, `msg="still logged"`)
+	assert.Contains(t, logFileContent, "usr.id=10")
+	assert.Contains(t, logFileContent, `formatter_error="encoder broke"`)
+	assert.EqualValues(t, 1, logger.FormatErrorCount())
+}
+
+func TestSplitOutputByLevel(t *testing.T) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SplitOutputByLevel: true, ErrorOutput: errOut})
+	assert.Equal(t, os.Stdout, logger.Out)
+	logger.Out = out
+	logger.Level = logrus.InfoLevel
+
+	logger.Info("regular line")
+	logger.Warn("warning line")
+	logger.Error("error line")
+
+	assert.Contains(t, out.String(), "regular line")
+	assert.Contains(t, out.String(), "warning line")
+	assert.NotContains(t, out.String(), "error line")
+	assert.Contains(t, errOut.String(), `"message":"error line"`)
+	assert.NotContains(t, errOut.String(), "regular line")
+	assert.NotContains(t, errOut.String(), "warning line")
+}
+
+func TestSplitOutputByLevelAsync(t *testing.T) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SplitOutputByLevel: true, ErrorOutput: errOut})
+	logger.Out = out
+	logger.enableAsync(10, nil)
+
+	logger.Error("queued error")
+	assert.NoError(t, logger.Flush())
+	assert.Contains(t, errOut.String(), "queued error")
+	assert.NotContains(t, out.String(), "queued error")
+
+	// Formatting has no side effects on the error output.
+	_, err := logger.Formatter.Format(logrus.NewEntry(logger.Logger).WithField("usr.id", 10))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(errOut.String(), "\n"))
+	assert.NoError(t, logger.Close())
+}
+
+func TestMaxMessageLength(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{MaxMessageLength: 10})
+	logger.Out = out
+	logger.Level = logrus.InfoLevel
+
+	payload := strings.Repeat("x", 30)
+	logger.WithField("payload", payload).Info("dumping payload " + payload)
+	logger.Info("short")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"message":"dumping pa…[truncated 36 bytes]"`)
+	assert.Contains(t, lines[0], `"payload":"`+payload+`"`)
+	assert.Contains(t, lines[1], `"message":"short"`)
+
+	t.Run("multi-byte runes are not split", func(t *testing.T) {
+		out.Reset()
+		logger.Info("ééééééééé")
+		assert.Contains(t, out.String(), `"message":"ééééé…[truncated 8 bytes]"`)
+	})
+}
+
+func TestFlattenNestedFields(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{FlattenNestedFields: true})
+	logger.Out = out
+	logger.Level = logrus.InfoLevel
+
+	entry := logger.NewEntry().
+		WithTimings(map[string]time.Duration{"auth": 3 * time.Millisecond}).
+		WithChange("name", "old", "new")
+	entry.Info("flattened")
+
+	assert.Contains(t, out.String(), `"timings_ms.auth":3`)
+	assert.Contains(t, out.String(), `"changes.name.before":"old"`)
+	assert.Contains(t, out.String(), `"changes.name.after":"new"`)
+	assert.NotContains(t, out.String(), `"timings_ms":{`)
+	assert.Contains(t, entry.Data, "timings_ms", "the entry itself is not modified")
+}
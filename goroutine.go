@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// goroutineIDHook stamps entries with the ID of the goroutine logging them.
+// The ID is parsed out of runtime.Stack, which is too slow to leave on
+// outside of debugging.
+type goroutineIDHook struct{}
+
+func (h *goroutineIDHook) Fire(entry *logrus.Entry) error {
+	if id, ok := goroutineID(); ok {
+		entry.Data[FieldGoroutineID] = id
+	}
+	return nil
+}
+
+func (h *goroutineIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// goroutineID parses the "goroutine N [running]:" header of the current
+// goroutine's stack.
+func goroutineID() (uint64, bool) {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if i := bytes.IndexByte(stack, ' '); i >= 0 {
+		stack = stack[:i]
+	}
+	id, err := strconv.ParseUint(string(stack), 10, 64)
+	return id, err == nil
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludeGoroutineID(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{IncludeGoroutineID: true})
+	logger.Out = out
+	logger.Level = logrus.InfoLevel
+
+	logger.Info("chatty")
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	id, ok := line["goroutine_id"].(float64)
+	assert.True(t, ok, "goroutine_id should be numeric")
+	assert.Positive(t, id)
+
+	t.Run("off by default", func(t *testing.T) {
+		out.Reset()
+		logger := new(false, LoggingConfig{})
+		logger.Out = out
+		logger.Info("chatty")
+		assert.NotContains(t, out.String(), "goroutine_id")
+	})
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// HealthCheck returns an error describing every degraded part of l: an
+// output that can no longer be written to or an open Bugsnag circuit. It
+// returns nil when l is healthy. See NewHealthCheck to also report dropped
+// entries.
+func (l *Logger) HealthCheck() error {
+	var errs []error
+	switch out := l.Out.(type) {
+	case nil:
+		errs = append(errs, errors.New("logging: no output"))
+	case *os.File:
+		if _, err := out.Stat(); err != nil {
+			errs = append(errs, fmt.Errorf("logging: output unusable: %w", err))
+		}
+	}
+	if state := l.BugsnagCircuitState(); state != CircuitClosed {
+		errs = append(errs, fmt.Errorf("logging: bugsnag circuit %s", state))
+	}
+	return errors.Join(errs...)
+}
+
+// NewHealthCheck returns a check that fails like HealthCheck and also when
+// async entries were dropped since its previous call. Each probe, e.g.
+// readiness and liveness, needs its own check so that neither misses drops
+// the other has seen. The check is safe for concurrent use.
+func (l *Logger) NewHealthCheck() func() error {
+	var checked atomic.Uint64
+	checked.Store(l.DroppedCount())
+	return func() error {
+		errs := []error{l.HealthCheck()}
+		dropped := l.DroppedCount()
+		if previous := checked.Swap(dropped); dropped > previous {
+			errs = append(errs, fmt.Errorf("logging: %d entries dropped since last check", dropped-previous))
+		}
+		return errors.Join(errs...)
+	}
+}
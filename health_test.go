@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bugsnag/bugsnag-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheck(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		logger := new(true, LoggingConfig{})
+		assert.NoError(t, logger.HealthCheck())
+	})
+	t.Run("async overflow", func(t *testing.T) {
+		writer := &blockingWriter{started: make(chan struct{}, 10), release: make(chan struct{})}
+		logger := new(false, LoggingConfig{})
+		logger.Out = writer
+		logger.enableAsync(1, nil)
+		readiness, liveness := logger.NewHealthCheck(), logger.NewHealthCheck()
+
+		logger.Info("line 1")
+		<-writer.started
+		logger.Info("line 2")
+		logger.Info("line 3")
+
+		assert.NoError(t, logger.HealthCheck())
+		assert.ErrorContains(t, readiness(), "1 entries dropped since last check")
+		assert.NoError(t, readiness(), "drops are reported once")
+		assert.ErrorContains(t, liveness(), "1 entries dropped since last check", "drops seen by another check")
+
+		close(writer.release)
+		assert.NoError(t, logger.Close())
+	})
+	t.Run("closed output", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+		logger := new(false, LoggingConfig{})
+		logger.Out = file
+
+		assert.ErrorContains(t, logger.HealthCheck(), "output unusable")
+	})
+	t.Run("open bugsnag circuit", func(t *testing.T) {
+		logger := new(false, LoggingConfig{})
+		breaker := newCircuitBreaker(1, time.Minute)
+		breaker.record(assert.AnError)
+		logger.bugsnag = &bugsnagHook{breaker: breaker}
+		logger.Hooks.Add(logger.bugsnag)
+
+		assert.ErrorContains(t, logger.HealthCheck(), "bugsnag circuit open")
+		assert.ErrorContains(t, logger.NewHealthCheck()(), "bugsnag circuit open")
+	})
+	t.Run("outside notify release stages", func(t *testing.T) {
+		swapBugsnagConfigForTest(t)
+		bugsnag.Config.APIKey = "0123456789abcdef0123456789abcdef"
+		bugsnag.Config.ReleaseStage = "staging"
+		bugsnag.Config.NotifyReleaseStages = []string{"production"}
+
+		logger := new(true, LoggingConfig{})
+		logger.Out = io.Discard
+		defer logger.Close()
+		for i := 0; i < 10; i++ {
+			logger.Error("not reported in staging")
+		}
+		assert.NoError(t, logger.HealthCheck())
+	})
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *Logger
+}
+
+// NewLoggingTransport wraps next so that every outbound request is logged
+// through logger with its method, host, status code and duration. When the
+// request context carries a Datadog span, the line is correlated with it and
+// the span is propagated to the remote service in the request headers.
+// A nil next uses http.DefaultTransport. A nil logger uses Default at the
+// time of each request and logs nothing while there is none.
+func NewLoggingTransport(next http.RoundTripper, logger *Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{next: next, logger: logger}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		req = req.Clone(ctx)
+		_ = tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header))
+	}
+
+	start := clock()
+	resp, err := t.next.RoundTrip(req)
+	logger := orDefault(t.logger)
+	if logger == nil {
+		return resp, err
+	}
+	entry := logger.WithDDTrace(ctx).
+		WithHTTPMethod(req.Method).
+		WithStringFieldIgnoreEmpty(FieldHTTPHost, req.URL.Host).
+		WithDuration(since(start))
+	if err != nil {
+		entry.WithError(err).Warn("outbound request failed")
+		return resp, err
+	}
+	entry.withStatusCode(resp.StatusCode).Info("outbound request")
+	return resp, nil
+}
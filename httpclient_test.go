@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var traceHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceHeader = r.Header.Get("x-datadog-trace-id")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{})
+	logger.Out = logFile.in
+	logger.Level = logrus.DebugLevel
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	started := false
+	SetClockForTest(func() time.Time {
+		if !started {
+			started = true
+			return start
+		}
+		return start.Add(250 * time.Millisecond)
+	})
+	defer SetClockForTest(nil)
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	req, err := http.NewRequestWithContext(tracer.ContextWithSpan(context.Background(), span), http.MethodPost, server.URL+"/hooks", nil)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewLoggingTransport(nil, logger)}
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	logFileContent := logFile.getLogFileContent(t)
+	assert.Equal(t, 1, strings.Count(logFileContent, "\n"), "expected one line per round trip")
+	assert.Contains(t, logFileContent, `"message":"outbound request"`)
+	assert.Contains(t, logFileContent, `"http.method":"POST"`)
+	assert.Contains(t, logFileContent, `"http.host":"`+strings.TrimPrefix(server.URL, "http://")+`"`)
+	assert.Contains(t, logFileContent, `"http.status_code":"418"`)
+	assert.Contains(t, logFileContent, `"duration":250000000`)
+	assert.Contains(t, logFileContent, `"dd.trace_id":`)
+	assert.NotEmpty(t, traceHeader, "the span should be propagated to the server")
+}
+
+func TestLoggingTransportWithoutLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	previous := defaultLogger.Swap(nil)
+	defer defaultLogger.Store(previous)
+
+	client := &http.Client{Transport: NewLoggingTransport(nil, nil)}
+	assert.NotPanics(t, func() {
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	})
+}
@@ -0,0 +1,12 @@
+package logging
+
+// WithKafkaMessage adds the coordinates of a consumed Kafka message, the
+// counterpart of WithNSQMessageID. It takes plain values so that this
+// package does not depend on a Kafka client. Empty keys are dropped.
+func (e *Entry) WithKafkaMessage(topic string, partition int32, offset int64, key string) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldKafkaTopic, topic).
+		WithField(FieldKafkaPartition, partition).
+		WithField(FieldKafkaOffset, offset).
+		WithStringFieldIgnoreEmpty(FieldKafkaKey, key)
+}
@@ -0,0 +1,22 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithKafkaMessage(t *testing.T) {
+	defer Log.Snapshot()()
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	Log.NewEntry().WithKafkaMessage("catches", 3, 1042, "user-7").Info("consumed")
+	logFileContent := logFile.getLogFileContent(t)
+	assert.Contains(t, logFileContent, `"kafka_topic":"catches"`)
+	assert.Contains(t, logFileContent, `"kafka_partition":3`)
+	assert.Contains(t, logFileContent, `"kafka_offset":1042`)
+	assert.Contains(t, logFileContent, `"kafka_key":"user-7"`)
+}
@@ -2,12 +2,22 @@ package logging
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	stdlog "log"
-	"reflect"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bugsnag/bugsnag-go/v2"
@@ -23,8 +33,27 @@ var (
 	nsqWarnLevel  = nsq.LogLevelWarning.String()
 	nsqErrLevel   = nsq.LogLevelError.String()
 	Log           *Logger
+
+	// defaultLogger mirrors Log for code that logs from other goroutines
+	// while Shutdown may reset it.
+	defaultLogger atomic.Pointer[Logger]
 )
 
+// Default returns the logger set up by Init, or nil before Init and after
+// Shutdown. Unlike reading Log, it is safe while another goroutine calls
+// Shutdown, so package code logging on its own uses it.
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+// orDefault returns l, or Default if l is nil.
+func orDefault(l *Logger) *Logger {
+	if l != nil {
+		return l
+	}
+	return Default()
+}
+
 type LoggingConfig struct {
 	LogLevel                   string
 	Environment                string
@@ -32,16 +61,213 @@ type LoggingConfig struct {
 	BugsnagAPIKey              string
 	BugsnagNotifyReleaseStages []string
 	BugsnagProjectPackages     []string
+	// GitSHA and BuildTime, usually injected with ldflags, are added to
+	// every entry as git_sha and build_time.
+	GitSHA    string
+	BuildTime string
+	// ErrorClassFunc picks the Bugsnag error class for a reported error.
+	// Returning "" keeps the class Bugsnag derived. Defaults to
+	// DefaultErrorClass.
+	ErrorClassFunc func(err error) string
+	// OutputFile is a path logs are appended to instead of stderr.
+	OutputFile string
+	// CompressOutput gzips OutputFile on the fly.
+	CompressOutput bool
+	// BugsnagNotifyTimeout bounds how long logging an error waits for
+	// Bugsnag to accept the report. Defaults to 5 seconds.
+	BugsnagNotifyTimeout time.Duration
+	// BugsnagBreakerThreshold is the number of consecutive failed notifies
+	// after which Bugsnag is skipped for BugsnagBreakerCooldown. Defaults to
+	// 5 failures and 30 seconds.
+	BugsnagBreakerThreshold int
+	BugsnagBreakerCooldown  time.Duration
+	// BugsnagThrottleInterval, when positive, sends at most one notify per
+	// interval for each error message, or throttle key set with
+	// WithThrottleKey.
+	BugsnagThrottleInterval time.Duration
+	// BugsnagSwallowErrors stops failed notifies from being printed to
+	// stderr by logrus. They are still counted, see BugsnagNotifyFailures.
+	BugsnagSwallowErrors bool
+	// CloudRegion is added to every entry as cloud_region. Defaults to the
+	// AWS_REGION environment variable.
+	CloudRegion string
+	// CloudAvailabilityZone is added to every entry as cloud_az.
+	CloudAvailabilityZone string
+	// ScrubPII masks email addresses and phone numbers in messages and
+	// string fields. Off by default as it runs regexps on every entry.
+	ScrubPII bool
+	// MaxFields, when positive, logs a one-time warning for each call site
+	// that logs an entry with more fields than this.
+	MaxFields int
+	// IncludeHostInfo adds hostname and pid to every entry.
+	IncludeHostInfo bool
+	// IncludeLogID adds a random 8 character log_id to every entry, so a
+	// single line can be referenced even among identical ones.
+	IncludeLogID bool
+	// IncludeGoroutineID adds the logging goroutine's goroutine_id to every
+	// entry, for hunting goroutine leaks. It is slow; enable it only while
+	// debugging.
+	IncludeGoroutineID bool
+	// TrackErrorStats counts logged errors by error class, see ErrorStats.
+	TrackErrorStats bool
+	// OTLPEndpoint, when set, also exports every entry to the OTLP/HTTP
+	// collector at this URL, e.g. http://otel-collector:4318.
+	OTLPEndpoint string
+	// CloudWatchGroup and CloudWatchStream, when set, also send every entry
+	// to that CloudWatch Logs stream through the client returned by
+	// NewCloudWatchClient for CloudWatchRegion.
+	CloudWatchGroup     string
+	CloudWatchStream    string
+	CloudWatchRegion    string
+	NewCloudWatchClient func(region string) (CloudWatchClient, error)
+	// SampleRate, when between 0 and 1, keeps only that fraction of
+	// entries. Entries at Error level and above and entries carrying an
+	// error are always kept. The decision is made once per entry and
+	// honoured by the output, ErrorOutput, CloudWatch, OTLP and outputs
+	// added with AddFormattedOutput; hooks added with AddHook see every
+	// entry.
+	SampleRate float64
+	// SplitOutputByLevel writes Error, Fatal and Panic entries to ErrorOutput
+	// and everything else to stdout, or OutputFile when set.
+	SplitOutputByLevel bool
+	// ErrorOutput receives Error, Fatal and Panic entries when
+	// SplitOutputByLevel is set. Defaults to stderr.
+	ErrorOutput io.Writer
+	// ClientPlatformHeader and ClientVersionHeader are the request headers
+	// Logger.WithClientFromHeaders and NewRecoveryMiddleware read the client
+	// platform and version from. Default to X-Client-Platform and
+	// X-Client-Version.
+	ClientPlatformHeader string
+	ClientVersionHeader  string
+	// AsyncBufferSize, when positive, queues up to that many entries for a
+	// background writer instead of writing synchronously. Entries logged
+	// while the queue is full are dropped.
+	AsyncBufferSize int
+	// OnDrop is called with the number of entries dropped because the async
+	// queue was full. It runs on a background goroutine, possibly batching
+	// several drops into one call, so it may log.
+	OnDrop func(count int)
+	// Format is either "json" (the default) or "text".
+	Format string
+	// Encoder replaces the formatter selected by Format.
+	Encoder Encoder
+	// SortFields emits "text" fields in key order, making output
+	// deterministic. "json" output is always sorted by key.
+	SortFields bool
+	// DisableTimestamp omits the time field, for collectors that stamp
+	// their own.
+	DisableTimestamp bool
+	// Color forces colored "text" output on or off. When nil, colors are
+	// used only if the output is a terminal. Ignored for "json".
+	Color *bool
+	// ErrorField is the key errors attached with WithError are logged and
+	// looked up for Bugsnag under. It is shared by every logger in the
+	// process, as logrus keeps it globally. Defaults to error.message.
+	ErrorField string
+	// FlattenNestedFields emits nested objects as dotted top-level keys,
+	// e.g. timings_ms.auth, for backends that do not index nested JSON.
+	FlattenNestedFields bool
+	// FatalBehavior is what Fatal does once the entry is logged and Bugsnag
+	// notified: "exit" (the default) closes the logger, waiting up to 5s for
+	// buffered output and exporters, and exits with status 1, "panic" panics
+	// with a *FatalExitError, e.g. in tests, and "noop" returns.
+	FatalBehavior string
+	// PagerDutyRoutingKey, when set, triggers a PagerDuty incident through
+	// the Events v2 API for Fatal and Panic entries, at most once a minute.
+	PagerDutyRoutingKey string
+	// MaxMessageLength, when positive, truncates longer messages to that
+	// many bytes. Fields are not truncated.
+	MaxMessageLength int
+	// MaxFieldValueLength, when positive, truncates longer string field
+	// values to that many bytes, in the output and in Bugsnag reports.
+	MaxFieldValueLength int
 }
 
 type Logger struct {
 	*logrus.Logger
+	stats   *loggerStats
+	onClose []func() error
+	// outputMu serializes SetOutput, and is shared with derived loggers.
+	outputMu *sync.Mutex
+	// config is the configuration l was created with, if any.
+	config LoggingConfig
+	// formatter is the formatter before Error entries are split off.
+	formatter logrus.Formatter
+	// errOutput writes Error entries to ErrorOutput with SplitOutputByLevel.
+	errOutput *formattedOutputHook
+	// fields are added to every entry, see With.
+	fields logrus.Fields
+	// sampler decides which entries SampleRate drops, if it is set.
+	sampler *samplingHook
+	// cloudWatch exports entries when CloudWatchGroup is set.
+	cloudWatch *cloudWatchHook
+	// bugsnag reports Error entries, if l was created with Bugsnag.
+	bugsnag *bugsnagHook
+	// errorStats counts errors when TrackErrorStats is set.
+	errorStats *errorStatsHook
 }
 
-type bugsnagHook struct{}
+// loggerStats holds counters about the logger itself.
+type loggerStats struct {
+	dropped      atomic.Uint64
+	formatErrors atomic.Uint64
+}
+
+const defaultBugsnagNotifyTimeout = 5 * time.Second
+
+// bugsnagQueueSize bounds the Error reports waiting for delivery. Reports
+// logged while it is full are dropped and count as notify failures.
+const bugsnagQueueSize = 100
+
+const defaultErrorField = "error.message"
+
+var errBugsnagTimeout = errors.New("bugsnag notify timed out")
+
+// bugsnagTabPrefix marks fields reported in a Bugsnag metadata tab of their
+// own, e.g. bugsnag.db.query is reported as query in the db tab.
+const bugsnagTabPrefix = "bugsnag."
+
+type bugsnagHook struct {
+	notify   func(err error, rawData ...interface{}) error
+	timeout  time.Duration
+	breaker  *circuitBreaker
+	throttle *throttle
+	// swallowErrors drops notify errors instead of returning them to logrus,
+	// which would print them to stderr.
+	swallowErrors bool
+	failures      atomic.Uint64
+
+	// queue feeds the worker delivering Error reports in the background.
+	// Hooks not made by newBugsnagHook have none and deliver inline.
+	queue   chan bugsnagReport
+	pending sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// bugsnagReport is a report waiting for the Bugsnag worker, with the logger
+// its entry was logged through, for warnings about the delivery.
+type bugsnagReport struct {
+	logger   *logrus.Logger
+	err      error
+	metadata bugsnag.MetaData
+}
+
+// defaultFieldsHook adds fields to every entry that does not already set
+// them.
+type defaultFieldsHook struct {
+	fields logrus.Fields
+}
+
+// hostInfoHook stamps every entry with the hostname and pid, both looked up
+// once when the hook is created.
+type hostInfoHook struct {
+	hostname string
+	pid      int
+}
 
 func (l Logger) getNSQLogLevel() nsq.LogLevel {
-	switch l.Level {
+	switch l.GetLevel() {
 	case logrus.DebugLevel:
 		return nsq.LogLevelDebug
 	case logrus.InfoLevel:
@@ -70,7 +296,7 @@ type NSQLogger struct{ *Entry }
 // NewNSQLogrusLogger returns a new NSQLogger with the provided log level mapped
 // to nsq.LogLevel for easily plugging into nsq.SetLogger.
 func (l *Logger) NSQLogger() (NSQLogger, nsq.LogLevel) {
-	return NSQLogger{l.NewEntry().WithField("component", "nsq")}, l.getNSQLogLevel()
+	return NSQLogger{l.NewEntry().WithComponent("nsq")}, l.getNSQLogLevel()
 }
 
 // Output implements stdlib log.Logger.Output using logrus
@@ -94,12 +320,279 @@ func (n NSQLogger) Output(_ int, s string) error {
 	return nil
 }
 
+// WrapLogger adapts a logrus logger created elsewhere to our API.
+func WrapLogger(l *logrus.Logger) *Logger {
+	return &Logger{Logger: l, stats: &loggerStats{}, outputMu: &sync.Mutex{}}
+}
+
+// WrapEntry adapts a logrus entry created elsewhere to our API.
+func WrapEntry(e *logrus.Entry) *Entry {
+	return &Entry{e}
+}
+
+// With returns a logger that adds fields to every entry it logs, for
+// storing in a struct and reusing. Fields set on an entry take precedence.
+//
+// The derived logger is a view of l: it logs through the same logrus logger,
+// so it is safe for concurrent use alongside l, and its level, output,
+// formatter and hooks are those of l, including later changes to them.
+func (l *Logger) With(fields map[string]interface{}) *Logger {
+	base := make(logrus.Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		base[k] = v
+	}
+	for k, v := range fields {
+		base[k] = v
+	}
+	derived := *l
+	derived.fields = base
+	// Exporters are closed by l.
+	derived.onClose = nil
+	return &derived
+}
+
+// The level methods of logrus.Logger are overridden so that they carry the
+// fields of loggers derived with With.
+
+func (l *Logger) WithFields(fields logrus.Fields) *logrus.Entry {
+	return l.NewEntry().Entry.WithFields(fields)
+}
+
+func (l *Logger) WithTime(t time.Time) *logrus.Entry {
+	return l.NewEntry().Entry.WithTime(t)
+}
+
+func (l *Logger) Log(level logrus.Level, args ...interface{}) {
+	l.NewEntry().Log(level, args...)
+}
+
+func (l *Logger) Logf(level logrus.Level, format string, args ...interface{}) {
+	l.NewEntry().Logf(level, format, args...)
+}
+
+func (l *Logger) Logln(level logrus.Level, args ...interface{}) {
+	l.NewEntry().Logln(level, args...)
+}
+
+func (l *Logger) Trace(args ...interface{}) {
+	l.NewEntry().Trace(args...)
+}
+
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.NewEntry().Tracef(format, args...)
+}
+
+func (l *Logger) Traceln(args ...interface{}) {
+	l.NewEntry().Traceln(args...)
+}
+
+func (l *Logger) Debug(args ...interface{}) {
+	l.NewEntry().Debug(args...)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.NewEntry().Debugf(format, args...)
+}
+
+func (l *Logger) Debugln(args ...interface{}) {
+	l.NewEntry().Debugln(args...)
+}
+
+func (l *Logger) Info(args ...interface{}) {
+	l.NewEntry().Info(args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.NewEntry().Infof(format, args...)
+}
+
+func (l *Logger) Infoln(args ...interface{}) {
+	l.NewEntry().Infoln(args...)
+}
+
+func (l *Logger) Warn(args ...interface{}) {
+	l.NewEntry().Warn(args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.NewEntry().Warnf(format, args...)
+}
+
+func (l *Logger) Warnln(args ...interface{}) {
+	l.NewEntry().Warnln(args...)
+}
+
+func (l *Logger) Warning(args ...interface{}) {
+	l.NewEntry().Warning(args...)
+}
+
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.NewEntry().Warningf(format, args...)
+}
+
+func (l *Logger) Warningln(args ...interface{}) {
+	l.NewEntry().Warningln(args...)
+}
+
+func (l *Logger) Error(args ...interface{}) {
+	l.NewEntry().Error(args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.NewEntry().Errorf(format, args...)
+}
+
+func (l *Logger) Errorln(args ...interface{}) {
+	l.NewEntry().Errorln(args...)
+}
+
+func (l *Logger) Fatal(args ...interface{}) {
+	l.NewEntry().Fatal(args...)
+}
+
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.NewEntry().Fatalf(format, args...)
+}
+
+func (l *Logger) Fatalln(args ...interface{}) {
+	l.NewEntry().Fatalln(args...)
+}
+
+func (l *Logger) Panic(args ...interface{}) {
+	l.NewEntry().Panic(args...)
+}
+
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.NewEntry().Panicf(format, args...)
+}
+
+func (l *Logger) Panicln(args ...interface{}) {
+	l.NewEntry().Panicln(args...)
+}
+
 func (l *Logger) WithField(field string, value interface{}) *Entry {
 	return l.NewEntry().WithField(field, value)
 }
 
+func (l *Logger) Named(name string) *Entry {
+	return l.NewEntry().Named(name)
+}
+
+func (l *Logger) WithComponent(component string) *Entry {
+	return l.NewEntry().WithComponent(component)
+}
+
+// SessionLogger returns a base entry tagged with the session ID.
+func (l *Logger) SessionLogger(id string) *Entry {
+	return l.NewEntry().WithSessionID(id)
+}
+
 func (l *Logger) NewEntry() *Entry {
-	return &Entry{logrus.NewEntry(l.Logger)}
+	entry := logrus.NewEntry(l.Logger)
+	if len(l.fields) > 0 {
+		entry = entry.WithFields(l.fields)
+	}
+	return &Entry{entry}
+}
+
+// Snapshot captures the level, output and formatter of l and returns a
+// function restoring them, for tests that mutate a shared logger:
+//
+//	defer logging.Log.Snapshot()()
+func (l *Logger) Snapshot() func() {
+	level, out, formatter := l.GetLevel(), l.Out, l.Formatter
+	return func() {
+		l.SetLevel(level)
+		l.Logger.SetOutput(out)
+		l.SetFormatter(formatter)
+	}
+}
+
+// SetLogLevel changes the level of l at runtime, taking the same names as
+// LoggingConfig.LogLevel, and returns an error leaving the level unchanged
+// for any other name. Like logrus's SetLevel, GetLevel and IsLevelEnabled it
+// is safe to call while other goroutines log; assigning l.Level directly is
+// not.
+func (l *Logger) SetLogLevel(level string) error {
+	if !isKnownLogLevel(level) {
+		return fmt.Errorf("unknown LogLevel %q", level)
+	}
+	l.SetLevel(getLogrusLogLevel(level))
+	return nil
+}
+
+// levelOverrides are the levels of the AtLevel calls running on a logrus
+// logger, and its level from before the first of them.
+type levelOverrides struct {
+	base   logrus.Level
+	active []*logrus.Level
+}
+
+var (
+	levelOverridesMu sync.Mutex
+	// levelOverridesByLogger is keyed by the logrus logger, which loggers
+	// derived with With or wrapped again share.
+	levelOverridesByLogger = map[*logrus.Logger]*levelOverrides{}
+)
+
+// AtLevel sets the level of l, runs fn and restores the previous level.
+// The level is not scoped to the calling goroutine: anything else logging
+// through l or loggers derived from it while fn runs uses the temporary
+// level as well. Overlapping calls, nested or from different goroutines,
+// are tracked together: l logs at the most verbose level of the calls
+// still running, and at the level from before the first call once they all
+// have returned. A level set while fn runs, e.g. with SetLogLevel, is kept
+// when fn returns.
+func (l *Logger) AtLevel(level logrus.Level, fn func()) {
+	override := &level
+	l.pushLevel(override)
+	defer l.popLevel(override)
+	fn()
+}
+
+func (l *Logger) pushLevel(override *logrus.Level) {
+	levelOverridesMu.Lock()
+	defer levelOverridesMu.Unlock()
+	overrides, ok := levelOverridesByLogger[l.Logger]
+	if !ok {
+		overrides = &levelOverrides{base: l.GetLevel()}
+		levelOverridesByLogger[l.Logger] = overrides
+	} else if current := l.GetLevel(); current != overrides.level() {
+		overrides.base = current
+	}
+	overrides.active = append(overrides.active, override)
+	l.SetLevel(overrides.level())
+}
+
+func (l *Logger) popLevel(override *logrus.Level) {
+	levelOverridesMu.Lock()
+	defer levelOverridesMu.Unlock()
+	overrides := levelOverridesByLogger[l.Logger]
+	applied := overrides.level()
+	overrides.active = slices.DeleteFunc(overrides.active, func(active *logrus.Level) bool {
+		return active == override
+	})
+	// The level was changed while overridden, so it is kept.
+	if current := l.GetLevel(); current != applied {
+		overrides.base = current
+	} else {
+		l.SetLevel(overrides.level())
+	}
+	if len(overrides.active) == 0 {
+		delete(levelOverridesByLogger, l.Logger)
+	}
+}
+
+// level returns the most verbose active level, or base if there is none.
+func (o *levelOverrides) level() logrus.Level {
+	level := o.base
+	if len(o.active) > 0 {
+		level = *o.active[0]
+	}
+	for _, active := range o.active {
+		level = max(level, *active)
+	}
+	return level
 }
 
 func (l *Logger) WithDDTrace(ctx context.Context) *Entry {
@@ -110,20 +603,145 @@ func (l *Logger) WithError(err error) *Entry {
 	return l.NewEntry().WithError(bugsnag_errors.New(err, 1))
 }
 
+// DeferClose closes c and logs msg at Warn level with the error if that
+// fails, for use as
+//
+//	defer logging.Log.DeferClose(f, "closing file")
+func (l *Logger) DeferClose(c io.Closer, msg string) {
+	if err := c.Close(); err != nil {
+		l.NewEntry().WithError(err).Warn(msg)
+	}
+}
+
 func (e *Entry) WithField(field string, value interface{}) *Entry {
 	return &Entry{e.Entry.WithField(field, value)}
 }
 
+// Named sets logger_name to name, appending it with a dot to the name
+// already on the entry, e.g. "consumer.worker".
+func (e *Entry) Named(name string) *Entry {
+	if parent, ok := e.Data[FieldLoggerName].(string); ok && parent != "" {
+		name = parent + "." + name
+	}
+	return e.WithField(FieldLoggerName, name)
+}
+
+func (e *Entry) WithComponent(component string) *Entry {
+	return e.WithField(FieldComponent, component)
+}
+
+// Deprecated: use WithField(FieldService, "rutilus").
 func (e *Entry) WithRutilus() *Entry {
-	return &Entry{e.Entry.WithField("service", "rutilus")}
+	deprecated("WithRutilus")
+	return &Entry{e.Entry.WithField(FieldService, "rutilus")}
 }
 
 func (e *Entry) WithHTTPMethod(method string) *Entry {
-	return &Entry{e.Entry.WithField("http.method", method)}
+	return &Entry{e.Entry.WithField(FieldHTTPMethod, method)}
+}
+
+// RetryLog logs msg with err for one attempt of a retry loop, at Warn level
+// while retries remain and at Error level, reporting to Bugsnag, on the
+// final attempt. Attempts are counted from 1.
+func (e *Entry) RetryLog(attempt, maxAttempts int, err error, msg string) {
+	entry := e.WithStackDepth(1).
+		WithField(FieldAttempt, attempt).
+		WithField(FieldMaxAttempts, maxAttempts).
+		WithError(err)
+	if attempt < maxAttempts {
+		entry.Warn(msg)
+		return
+	}
+	entry.Error(msg)
 }
 
+// Timed runs fn and logs msg with its duration, at Info level if it
+// succeeds and at Error level with the error if it fails. It returns the
+// error of fn.
+func (e *Entry) Timed(msg string, fn func() error) error {
+	start := clock()
+	err := fn()
+	entry := e.WithStackDepth(1).WithDuration(since(start))
+	if err != nil {
+		entry.WithError(err).Error(msg)
+		return err
+	}
+	entry.Info(msg)
+	return nil
+}
+
+// WithURL adds u under field as scheme, host and path only. The query,
+// fragment and userinfo are left out as they often carry secrets or PII.
+// Nil URLs are ignored.
+func (e *Entry) WithURL(field string, u *url.URL) *Entry {
+	if u == nil {
+		return e
+	}
+	safe := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawPath: u.RawPath}
+	return e.WithField(field, safe.String())
+}
+
+// WithURLQuery is WithURL keeping the query, for URLs whose query is known
+// to be safe. Userinfo and fragment are still left out.
+func (e *Entry) WithURLQuery(field string, u *url.URL) *Entry {
+	if u == nil {
+		return e
+	}
+	safe := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}
+	return e.WithField(field, safe.String())
+}
+
+// WithRateLimit adds the state of the rate limit a request was checked
+// against, with the reset time in RFC3339.
+func (e *Entry) WithRateLimit(limit int, remaining int, resetAt time.Time) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldRateLimitLimit:     limit,
+		FieldRateLimitRemaining: remaining,
+		FieldRateLimitReset:     resetAt.Format(time.RFC3339Nano),
+	})}
+}
+
+// WithRoute adds the route template a request matched (e.g.
+// "/users/{id}") under http.route. Unlike the raw path it has low
+// cardinality, so it can be aggregated on. Empty templates are ignored.
+func (e *Entry) WithRoute(template string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldHTTPRoute, template)
+}
+
+// WithRouteFromRequest adds the http.ServeMux pattern r matched under
+// http.route, without its method. Requests not routed by a ServeMux are
+// ignored.
+func (e *Entry) WithRouteFromRequest(r *http.Request) *Entry {
+	pattern := r.Pattern
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+	return e.WithRoute(pattern)
+}
+
+// Deprecated: use HTTPResult, which also records the status class.
 func (e *Entry) WithHTTPResponseCode(code int) *Entry {
-	return &Entry{e.Entry.WithField("http.status_code", strconv.Itoa(code))}
+	deprecated("WithHTTPResponseCode")
+	return e.withStatusCode(code)
+}
+
+func (e *Entry) withStatusCode(code int) *Entry {
+	return &Entry{e.Entry.WithField(FieldHTTPStatusCode, strconv.Itoa(code))}
+}
+
+// HTTPResult logs msg with the status code and its class (e.g. "4xx"),
+// at Error level for 5xx codes, Warn for 4xx and Info otherwise.
+func (e *Entry) HTTPResult(code int, msg string) {
+	entry := e.withStatusCode(code).
+		WithField(FieldHTTPStatusClass, fmt.Sprintf("%dxx", code/100))
+	switch {
+	case code >= 500:
+		entry.Error(msg)
+	case code >= 400:
+		entry.Warn(msg)
+	default:
+		entry.Info(msg)
+	}
 }
 
 // WithStringFieldIgnoreEmpty adds string value is empty - otherwise noop
@@ -135,7 +753,11 @@ func (e *Entry) WithStringFieldIgnoreEmpty(field string, value string) *Entry {
 }
 
 func (e *Entry) WithUser(userID uint64) *Entry {
-	return e.WithField("usr.id", userID)
+	return e.WithField(FieldUserID, userID)
+}
+
+func (e *Entry) WithSessionID(id string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldSessionID, id)
 }
 
 // WithEvent parses and event given as string and returns an entry
@@ -150,39 +772,247 @@ func (e *Entry) WithEvent(event string) *Entry {
 	if len(split) == 2 {
 		objectID, _ = strconv.Atoi(split[1])
 		return e.
-			WithStringFieldIgnoreEmpty("event_name", eventName).
-			WithField("object_id", objectID)
+			WithStringFieldIgnoreEmpty(FieldEventName, eventName).
+			WithField(FieldObjectID, objectID)
 	} else if len(split) == 3 {
 		objectID, _ = strconv.Atoi(split[1])
 		subjectID, _ = strconv.Atoi(split[2])
 		return e.
-			WithStringFieldIgnoreEmpty("event_name", eventName).
-			WithField("object_id", objectID).
-			WithField("subject_id", subjectID)
+			WithStringFieldIgnoreEmpty(FieldEventName, eventName).
+			WithField(FieldObjectID, objectID).
+			WithField(FieldSubjectID, subjectID)
 	}
-	return e.WithStringFieldIgnoreEmpty("event", event)
+	return e.WithStringFieldIgnoreEmpty(FieldEvent, event)
+}
+
+// WithParentEvent adds the ID of the event this one belongs to, so event
+// trees can be rebuilt. Empty IDs are ignored.
+func (e *Entry) WithParentEvent(parentID string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldParentEventID, parentID)
+}
+
+// WithCatch adds the species, water body and fishing method of a catch,
+// dropping empty values.
+func (e *Entry) WithCatch(species string, waterBody string, method string) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldSpecies, species).
+		WithStringFieldIgnoreEmpty(FieldWaterBody, waterBody).
+		WithStringFieldIgnoreEmpty(FieldMethod, method)
+}
+
+// WithMedia adds the ID, type (e.g. "image" or "video") and size of a photo
+// or video being processed.
+func (e *Entry) WithMedia(mediaID string, mediaType string, sizeBytes int64) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldMediaID:        mediaID,
+		FieldMediaType:      mediaType,
+		FieldMediaSizeBytes: sizeBytes,
+	})}
 }
 
 func (e *Entry) WithRelation(relation string) *Entry {
-	return e.WithStringFieldIgnoreEmpty("relation", relation)
+	return e.WithStringFieldIgnoreEmpty(FieldRelation, relation)
+}
+
+// WithResource adds the type and ID of the resource being operated on,
+// dropping empty values.
+func (e *Entry) WithResource(kind string, id string) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldResourceType, kind).
+		WithStringFieldIgnoreEmpty(FieldResourceID, id)
+}
+
+// WithMoney adds an amount in the currency's minor unit (e.g. cents) under
+// <field>_amount_minor and its ISO 4217 code under <field>_currency.
+func (e *Entry) WithMoney(field string, amountMinor int64, currency string) *Entry {
+	return e.
+		WithField(field+"_amount_minor", amountMinor).
+		WithStringFieldIgnoreEmpty(field+"_currency", strings.ToUpper(currency))
+}
+
+func (e *Entry) WithDBOperation(op string, table string, rowsAffected int64) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldDBOperation, op).
+		WithStringFieldIgnoreEmpty(FieldDBTable, table).
+		WithField(FieldDBRowsAffected, rowsAffected)
+}
+
+func (e *Entry) WithRegion(region string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldCloudRegion, region)
+}
+
+func (e *Entry) WithAvailabilityZone(az string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldCloudAvailabilityZone, az)
+}
+
+func (e *Entry) WithIdempotencyKey(key string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldIdempotencyKey, key)
 }
 
 func (e *Entry) WithNSQMessageID(id nsq.MessageID) *Entry {
-	return e.WithStringFieldIgnoreEmpty("nsq_message_id", fmt.Sprintf("%s", id))
+	return e.WithStringFieldIgnoreEmpty(FieldNSQMessageID, fmt.Sprintf("%s", id))
+}
+
+// WithMessageAge adds the time since m was published under message_age_ms.
+// Nil messages and messages without a timestamp are ignored.
+func (e *Entry) WithMessageAge(m *nsq.Message) *Entry {
+	if m == nil || m.Timestamp == 0 {
+		return e
+	}
+	return e.WithField(FieldMessageAge, since(time.Unix(0, m.Timestamp)).Milliseconds())
+}
+
+// WithWorkerStats adds a worker pool's queue_depth and active_workers.
+func (e *Entry) WithWorkerStats(queueDepth int, activeWorkers int) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldQueueDepth:    queueDepth,
+		FieldActiveWorkers: activeWorkers,
+	})}
+}
+
+// WithRuntimeStats adds the number of goroutines, heap_alloc_bytes and
+// num_gc. It calls runtime.ReadMemStats, which stops the world, so keep it
+// off hot paths.
+func (e *Entry) WithRuntimeStats() *Entry {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldGoroutines:     runtime.NumGoroutine(),
+		FieldHeapAllocBytes: stats.HeapAlloc,
+		FieldNumGC:          stats.NumGC,
+	})}
 }
 
 func (e *Entry) WithDuration(d time.Duration) *Entry {
 	return e.
-		WithField("duration", d.Nanoseconds())
+		WithField(FieldDuration, d.Nanoseconds())
+}
+
+// WithTimings adds the duration of each phase in milliseconds under the
+// timings_ms object.
+func (e *Entry) WithTimings(timings map[string]time.Duration) *Entry {
+	if len(timings) == 0 {
+		return e
+	}
+	timingsMS := make(map[string]int64, len(timings))
+	for phase, d := range timings {
+		timingsMS[phase] = d.Milliseconds()
+	}
+	return e.WithField(FieldTimings, timingsMS)
+}
+
+// WithInterval adds the window a job processes, with its bounds in
+// RFC3339 and its length in milliseconds.
+func (e *Entry) WithInterval(from, to time.Time) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldIntervalFrom:       from.Format(time.RFC3339Nano),
+		FieldIntervalTo:         to.Format(time.RFC3339Nano),
+		FieldIntervalDurationMS: to.Sub(from).Milliseconds(),
+	})}
+}
+
+// WithTime timestamps the entry with t instead of the time it is logged,
+// for backfills and replays.
+func (e *Entry) WithTime(t time.Time) *Entry {
+	return &Entry{e.Entry.WithTime(t)}
 }
 
 func (e *Entry) WithE2EDuration(d time.Duration) *Entry {
 	return e.WithField(
-		"e2e_duration",
+		FieldE2EDuration,
 		d.Nanoseconds(),
 	)
 }
 
+// WithPercentage clamps ratio to [0,1] and adds it as a percentage rounded
+// to one decimal under <field>_pct. NaN ratios are dropped.
+func (e *Entry) WithPercentage(field string, ratio float64) *Entry {
+	if math.IsNaN(ratio) {
+		return e
+	}
+	ratio = math.Max(0, math.Min(1, ratio))
+	return e.WithField(field+"_pct", math.Round(ratio*1000)/10)
+}
+
+var knownOutcomes = map[string]bool{
+	"success": true,
+	"failure": true,
+	"partial": true,
+	"skipped": true,
+}
+
+// WithOutcome adds the result of an operation under outcome. Values outside
+// success, failure, partial and skipped are still stored, but a warning is
+// logged so they can be fixed at the call site.
+func (e *Entry) WithOutcome(outcome string) *Entry {
+	entry := e.WithField(FieldOutcome, outcome)
+	if !knownOutcomes[outcome] {
+		entry.Warnf("unknown outcome %q", outcome)
+	}
+	return entry
+}
+
+// WithChange records the before and after values of field under the changes
+// object. Repeated calls accumulate into the same object.
+func (e *Entry) WithChange(field string, before, after interface{}) *Entry {
+	changes := map[string]interface{}{}
+	if existing, ok := e.Data[FieldChanges].(map[string]interface{}); ok {
+		for k, v := range existing {
+			changes[k] = v
+		}
+	}
+	changes[field] = map[string]interface{}{
+		"before": before,
+		"after":  after,
+	}
+	return e.WithField(FieldChanges, changes)
+}
+
+// WithCappedSlice adds at most max items under field, along with
+// <field>_total and <field>_truncated so large collections stay bounded.
+func (e *Entry) WithCappedSlice(field string, items []interface{}, max int) *Entry {
+	if max < 0 {
+		max = 0
+	}
+	total := len(items)
+	truncated := total > max
+	if truncated {
+		items = items[:max]
+	}
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		field:                items,
+		field + "_total":     total,
+		field + "_truncated": truncated,
+	})}
+}
+
+// MaxLoggedBytes caps how many bytes WithBytes encodes.
+const MaxLoggedBytes = 256
+
+// WithBytes adds b under field as a "hex" or "base64" string. Only the first
+// MaxLoggedBytes are encoded; longer slices also get <field>_total and
+// <field>_truncated. Unknown encodings fall back to base64 and are named
+// under <field>_encoding_error.
+func (e *Entry) WithBytes(field string, b []byte, encoding string) *Entry {
+	fields := logrus.Fields{}
+	if len(b) > MaxLoggedBytes {
+		fields[field+"_total"] = len(b)
+		fields[field+"_truncated"] = true
+		b = b[:MaxLoggedBytes]
+	}
+
+	switch encoding {
+	case "hex":
+		fields[field] = hex.EncodeToString(b)
+	case "base64":
+		fields[field] = base64.StdEncoding.EncodeToString(b)
+	default:
+		fields[field] = base64.StdEncoding.EncodeToString(b)
+		fields[field+"_encoding_error"] = fmt.Sprintf("unknown encoding %q, used base64", encoding)
+	}
+	return &Entry{e.Entry.WithFields(fields)}
+}
+
 func (e *Entry) WithFCM() *Entry {
 	return e.WithChannel("fcm")
 }
@@ -192,27 +1022,90 @@ func (e *Entry) WithNotificationlist() *Entry {
 }
 
 func (e *Entry) WithChannel(channel string) *Entry {
-	return e.WithField("channel", channel)
+	return e.WithField(FieldChannel, channel)
+}
+
+// WithCache adds the cache looked up and whether it was a hit.
+func (e *Entry) WithCache(name string, hit bool) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldCacheName: name,
+		FieldCacheHit:  hit,
+	})}
+}
+
+// WithDelivery adds the outcome of a push notification delivery, dropping
+// empty values.
+func (e *Entry) WithDelivery(provider string, status string, messageID string) *Entry {
+	return e.
+		WithStringFieldIgnoreEmpty(FieldDeliveryProvider, provider).
+		WithStringFieldIgnoreEmpty(FieldDeliveryStatus, status).
+		WithStringFieldIgnoreEmpty(FieldDeliveryMessageID, messageID)
 }
 
 func (e *Entry) WithError(err error) *Entry {
-	return &Entry{e.Entry.WithError(bugsnag_errors.New(err, 1))}
+	return &Entry{e.Entry.WithError(bugsnag_errors.New(err, 1+stackDepth(e.Context)))}
 }
 
+// WithErrors adds the messages of errs under errors and sets them, joined, as
+// the entry error so they are reported together to Bugsnag. Nil errors are
+// skipped and an empty slice is a noop.
+func (e *Entry) WithErrors(errs []error) *Entry {
+	messages := make([]string, 0, len(errs))
+	nonNil := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return e
+	}
+	return &Entry{e.Entry.
+		WithField(FieldErrors, messages).
+		WithError(bugsnag_errors.New(errors.Join(nonNil...), 1))}
+}
+
+// WithDDTrace adds the trace and span IDs of the span in ctx, and its
+// operation name under dd.name when the span exposes it. dd-trace-go's own
+// spans do not, only mocktracer's do; use WithSpanName to set dd.name with
+// the real tracer.
 func (e *Entry) WithDDTrace(ctx context.Context) *Entry {
 	var traceID, spanID uint64
 	span, ok := tracer.SpanFromContext(ctx)
 	if ok {
 		// there was a span in the context
 		traceID, spanID = span.Context().TraceID(), span.Context().SpanID()
-		return &Entry{e.Entry.WithFields(logrus.Fields{
-			"dd.trace_id": traceID,
-			"dd.span_id":  spanID,
-		})}
+		fields := logrus.Fields{
+			FieldDDTraceID: traceID,
+			FieldDDSpanID:  spanID,
+		}
+		// ddtrace.Span has no accessor for the operation name, but
+		// mocktracer's spans provide one.
+		if named, ok := span.(interface{ OperationName() string }); ok && named.OperationName() != "" {
+			fields[FieldDDName] = named.OperationName()
+		}
+		return &Entry{e.Entry.WithFields(fields)}
 	}
 	return e
 }
 
+// WithSpanName sets dd.name to the operation name of the current span,
+// which WithDDTrace cannot read from dd-trace-go's spans.
+func (e *Entry) WithSpanName(name string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldDDName, name)
+}
+
+// WithSpanLink references the span an operation was fanned out from under
+// dd.linked_trace_id and dd.linked_span_id, for logs emitted once the
+// context no longer carries it.
+func (e *Entry) WithSpanLink(traceID, spanID uint64) *Entry {
+	return &Entry{e.Entry.WithFields(logrus.Fields{
+		FieldDDLinkedTraceID: traceID,
+		FieldDDLinkedSpanID:  spanID,
+	})}
+}
+
 func Errorf(format string, a ...interface{}) *bugsnag_errors.Error {
 	return bugsnag_errors.New(fmt.Errorf(format, a...), 1)
 }
@@ -221,15 +1114,23 @@ func ErrorWithStacktrace(err error) *bugsnag_errors.Error {
 	return bugsnag_errors.New(err, 1)
 }
 
-func getLogrusLogLevel(level string) logrus.Level {
-	lookup := map[string]logrus.Level{
-		"ERROR":   logrus.ErrorLevel,
-		"WARNING": logrus.WarnLevel,
-		"INFO":    logrus.InfoLevel,
-		"DEBUG":   logrus.DebugLevel,
-	}
+// logLevels maps the names LoggingConfig.LogLevel takes to logrus levels.
+var logLevels = map[string]logrus.Level{
+	"ERROR":   logrus.ErrorLevel,
+	"WARNING": logrus.WarnLevel,
+	"INFO":    logrus.InfoLevel,
+	"DEBUG":   logrus.DebugLevel,
+}
+
+// isKnownLogLevel reports whether level is in logLevels or empty, which
+// means the default.
+func isKnownLogLevel(level string) bool {
+	_, ok := logLevels[level]
+	return ok || level == ""
+}
 
-	loglevel, ok := lookup[level]
+func getLogrusLogLevel(level string) logrus.Level {
+	loglevel, ok := logLevels[level]
 
 	if !ok {
 		loglevel = logrus.InfoLevel
@@ -239,12 +1140,17 @@ func getLogrusLogLevel(level string) logrus.Level {
 }
 
 func (b *bugsnagHook) Fire(entry *logrus.Entry) error {
+	if isReplayed(entry) {
+		return nil
+	}
 	var notifyErr error
 	switch err := entry.Data[logrus.ErrorKey].(type) {
 	case *bugsnag_errors.Error:
 		notifyErr = err
 	case error:
-		if entry.Message != "" {
+		// Skip the message when the error already starts with it, so the
+		// Bugsnag title doesn't read "boom: boom".
+		if entry.Message != "" && !strings.HasPrefix(err.Error(), entry.Message) {
 			notifyErr = fmt.Errorf("%s: %w", entry.Message, err)
 		} else {
 			notifyErr = err
@@ -256,21 +1162,167 @@ func (b *bugsnagHook) Fire(entry *logrus.Entry) error {
 	metadata := bugsnag.MetaData{}
 	metadata["metadata"] = make(map[string]interface{})
 	for key, val := range entry.Data {
-		if key != logrus.ErrorKey {
-			metadata["metadata"][key] = val
+		if key == logrus.ErrorKey {
+			continue
 		}
+		// bugsnag.<tab>.<key> fields go to their own tab.
+		if tabKey, ok := strings.CutPrefix(key, bugsnagTabPrefix); ok {
+			if tab, field, ok := strings.Cut(tabKey, "."); ok && tab != "" && field != "" {
+				metadata.Add(tab, field, val)
+				continue
+			}
+		}
+		metadata["metadata"][key] = val
 	}
 
-	skipStackFrames := 4
+	skipStackFrames := 4 + stackDepth(entry.Context)
 	errWithStack := bugsnag_errors.New(notifyErr, skipStackFrames)
-	bugsnagErr := bugsnag.Notify(errWithStack, metadata)
+
+	throttleKey, _ := entry.Data[FieldThrottleKey].(string)
+	if throttleKey == "" {
+		throttleKey = notifyErr.Error()
+	}
+	// The breaker goes first so that errors skipped while the circuit is
+	// open don't use up the throttle interval of their key.
+	if !b.breaker.allow() {
+		return nil
+	}
+	if !b.throttle.allow(throttleKey) {
+		b.breaker.release()
+		return nil
+	}
+
+	report := bugsnagReport{logger: entry.Logger, err: errWithStack, metadata: metadata}
+	// Fatal exits and Panic crashes right after the hooks run, so only
+	// Error reports are left to the worker.
+	if entry.Level == logrus.ErrorLevel && b.enqueue(report) {
+		return nil
+	}
+	return b.deliver(report)
+}
+
+// enqueue hands report to the worker, dropping it if the queue is full. It
+// returns false if there is no worker to hand it to.
+func (b *bugsnagHook) enqueue(report bugsnagReport) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.queue == nil || b.closed {
+		return false
+	}
+	b.pending.Add(1)
+	select {
+	case b.queue <- report:
+	default:
+		b.pending.Done()
+		b.failures.Add(1)
+	}
+	return true
+}
+
+func (b *bugsnagHook) run() {
+	for report := range b.queue {
+		// There is no logrus call to return the error to, so print it as
+		// logrus prints hook errors.
+		if err := b.deliver(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		}
+		b.pending.Done()
+	}
+}
+
+// deliver notifies Bugsnag, waiting up to the timeout, and records the
+// outcome with the breaker.
+func (b *bugsnagHook) deliver(report bugsnagReport) error {
+	done := make(chan error, 1)
+	go func() { done <- b.notify(report.err, report.metadata) }()
+	var bugsnagErr error
+	select {
+	case bugsnagErr = <-done:
+	case <-time.After(b.timeout):
+		logrus.NewEntry(report.logger).
+			WithField(FieldTimeout, b.timeout.String()).
+			Warn("bugsnag notify timed out")
+		bugsnagErr = errBugsnagTimeout
+	}
+
+	if b.breaker.record(bugsnagErr) {
+		logrus.NewEntry(report.logger).
+			WithField(FieldCooldown, b.breaker.cooldown.String()).
+			Warn("bugsnag circuit opened, skipping notifies")
+	}
 	if bugsnagErr != nil {
-		return bugsnagErr
+		b.failures.Add(1)
+	}
+	if bugsnagErr == errBugsnagTimeout || b.swallowErrors {
+		return nil
 	}
+	return bugsnagErr
+}
 
+// Close waits for the queued reports to be delivered and stops the worker.
+// Reports logged afterwards are delivered inline.
+func (b *bugsnagHook) Close() error {
+	b.mu.Lock()
+	if b.queue == nil || b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	b.pending.Wait()
 	return nil
 }
 
+// BugsnagNotifyFailures returns how many Bugsnag reports could not be
+// delivered or timed out, or 0 if l does not report to Bugsnag. Reports
+// skipped because of the release stage or a missing API key are not
+// failures, nor are those skipped by the throttle or an open circuit.
+func (l *Logger) BugsnagNotifyFailures() uint64 {
+	if l.bugsnag == nil {
+		return 0
+	}
+	return l.bugsnag.failures.Load()
+}
+
+// notifyBugsnag delivers a report synchronously, so that delivery failures
+// reach the circuit breaker. bugsnagHook calls it from its worker for Error
+// entries, so logging doesn't wait on it. Not notifying, because the release stage is not
+// in NotifyReleaseStages or there is no API key, is not a failure.
+func notifyBugsnag(err error, rawData ...interface{}) error {
+	config := bugsnag.Config
+	if config.APIKey == "" || !notifiesInReleaseStage(config) {
+		return nil
+	}
+	return bugsnag.Notify(err, append(rawData, true)...)
+}
+
+// notifiesInReleaseStage mirrors the release stage check bugsnag.Notify
+// makes before refusing to notify.
+func notifiesInReleaseStage(config bugsnag.Configuration) bool {
+	if config.NotifyReleaseStages == nil || config.ReleaseStage == "" {
+		return true
+	}
+	return slices.Contains(config.NotifyReleaseStages, config.ReleaseStage)
+}
+
+func newBugsnagHook(config LoggingConfig) *bugsnagHook {
+	timeout := config.BugsnagNotifyTimeout
+	if timeout <= 0 {
+		timeout = defaultBugsnagNotifyTimeout
+	}
+	b := &bugsnagHook{
+		notify:        notifyBugsnag,
+		timeout:       timeout,
+		breaker:       newCircuitBreaker(config.BugsnagBreakerThreshold, config.BugsnagBreakerCooldown),
+		throttle:      newThrottle(config.BugsnagThrottleInterval),
+		swallowErrors: config.BugsnagSwallowErrors,
+		queue:         make(chan bugsnagReport, bugsnagQueueSize),
+	}
+	go b.run()
+	return b
+}
+
 func (b *bugsnagHook) Levels() []logrus.Level {
 	return []logrus.Level{
 		logrus.ErrorLevel,
@@ -279,26 +1331,226 @@ func (b *bugsnagHook) Levels() []logrus.Level {
 	}
 }
 
+func (h *defaultFieldsHook) Fire(entry *logrus.Entry) error {
+	for key, value := range h.fields {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
+
+func (h *defaultFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// defaultFields returns the fields from config stamped on every entry.
+func defaultFields(config LoggingConfig) logrus.Fields {
+	fields := logrus.Fields{}
+	if config.GitSHA != "" {
+		fields[FieldGitSHA] = config.GitSHA
+	}
+	if config.BuildTime != "" {
+		fields[FieldBuildTime] = config.BuildTime
+	}
+	region := config.CloudRegion
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region != "" {
+		fields[FieldCloudRegion] = region
+	}
+	if config.CloudAvailabilityZone != "" {
+		fields[FieldCloudAvailabilityZone] = config.CloudAvailabilityZone
+	}
+	return fields
+}
+
+func newHostInfoHook() *hostInfoHook {
+	hostname, _ := os.Hostname()
+	return &hostInfoHook{hostname: hostname, pid: os.Getpid()}
+}
+
+func (h *hostInfoHook) Fire(entry *logrus.Entry) error {
+	if h.hostname != "" {
+		entry.Data[FieldHostname] = h.hostname
+	}
+	entry.Data[FieldPID] = h.pid
+	return nil
+}
+
+func (h *hostInfoHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func newFormatter(config LoggingConfig) logrus.Formatter {
+	if config.Encoder != nil {
+		return &encoderFormatter{encoder: config.Encoder}
+	}
+
+	fieldMap := logrus.FieldMap{
+		logrus.FieldKeyMsg:  "message",
+		logrus.FieldKeyFunc: "logger.method_name",
+		logrus.FieldKeyFile: "logger.name",
+	}
+
+	if config.Format == "text" {
+		formatter := &logrus.TextFormatter{
+			FullTimestamp:    true,
+			TimestampFormat:  time.RFC3339Nano,
+			DisableTimestamp: config.DisableTimestamp,
+			DisableSorting:   !config.SortFields,
+			FieldMap:         fieldMap,
+		}
+		if config.Color != nil {
+			formatter.ForceColors = *config.Color
+			formatter.DisableColors = !*config.Color
+		}
+		return formatter
+	}
+
+	return &logrus.JSONFormatter{
+		TimestampFormat:  time.RFC3339Nano,
+		DisableTimestamp: config.DisableTimestamp,
+		FieldMap:         fieldMap,
+	}
+}
+
+// FatalExitError is the panic value of Fatal with the "panic" FatalBehavior.
+type FatalExitError struct {
+	Code int
+}
+
+func (e *FatalExitError) Error() string {
+	return fmt.Sprintf("logging: fatal, exit status %d", e.Code)
+}
+
+// exitFlushTimeout bounds how long a Fatal entry waits for outputs and
+// exporters to flush before the process exits.
+const exitFlushTimeout = 5 * time.Second
+
+// osExit is replaced in tests.
+var osExit = os.Exit
+
+// exitFunc returns the logrus ExitFunc for behavior. Hooks, Bugsnag
+// included, have all run by the time it is called. Before exiting, l is
+// closed so buffered CloudWatch and OTLP records are delivered, giving up
+// after exitFlushTimeout.
+func (l *Logger) exitFunc(behavior string) func(code int) {
+	switch behavior {
+	case "panic":
+		return func(code int) { panic(&FatalExitError{Code: code}) }
+	case "noop":
+		return func(code int) {}
+	}
+	return func(code int) {
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			_ = l.Close()
+		}()
+		select {
+		case <-closed:
+		case <-time.After(exitFlushTimeout):
+		}
+		osExit(code)
+	}
+}
+
 func new(withBugsnag bool, config LoggingConfig) *Logger {
 	log := logrus.New()
-	logrus.ErrorKey = "error.message"
-	log.Formatter = &logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339Nano,
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyMsg:  "message",
-			logrus.FieldKeyFunc: "logger.method_name",
-			logrus.FieldKeyFile: "logger.name",
-		},
+	logger := WrapLogger(log)
+	logger.config = config
+	log.ExitFunc = logger.exitFunc(config.FatalBehavior)
+	logrus.ErrorKey = defaultErrorField
+	if config.ErrorField != "" {
+		logrus.ErrorKey = config.ErrorField
+	}
+	log.Formatter = newFallbackFormatter(newFormatter(config), logger.stats)
+	if config.FlattenNestedFields {
+		log.Formatter = &flatteningFormatter{formatter: log.Formatter}
+	}
+	if config.MaxMessageLength > 0 {
+		log.Formatter = &truncatingFormatter{formatter: log.Formatter, max: config.MaxMessageLength}
+	}
+	if config.SampleRate > 0 && config.SampleRate < 1 {
+		log.Formatter = &samplingFormatter{formatter: log.Formatter}
+		logger.sampler = newSamplingHook(config.SampleRate)
+	}
+	logger.formatter = log.Formatter
+	if config.SplitOutputByLevel {
+		errOut := config.ErrorOutput
+		if errOut == nil {
+			errOut = os.Stderr
+		}
+		log.Out = os.Stdout
+		log.Formatter = &belowErrorFormatter{formatter: log.Formatter}
+		logger.errOutput = &formattedOutputHook{out: errOut, formatter: logger.formatter, levels: errorLevels}
 	}
 	log.Level = getLogrusLogLevel(config.LogLevel)
 
+	if logger.sampler != nil {
+		log.Hooks.Add(logger.sampler)
+	}
+	log.Hooks.Add(&stackDepthHook{})
+	log.Hooks.Add(&logValuerHook{})
+
+	if config.ScrubPII {
+		log.Hooks.Add(&piiScrubHook{})
+	}
+
+	if fields := defaultFields(config); len(fields) > 0 {
+		log.Hooks.Add(&defaultFieldsHook{fields: fields})
+	}
+
+	if config.IncludeHostInfo {
+		log.Hooks.Add(newHostInfoHook())
+	}
+
+	if config.IncludeLogID {
+		log.Hooks.Add(&logIDHook{})
+	}
+
+	if config.IncludeGoroutineID {
+		log.Hooks.Add(&goroutineIDHook{})
+	}
+
+	if config.TrackErrorStats {
+		logger.errorStats = &errorStatsHook{counts: map[string]uint64{}}
+		log.Hooks.Add(logger.errorStats)
+	}
+
+	if config.MaxFields > 0 {
+		log.Hooks.Add(&maxFieldsHook{max: config.MaxFields})
+	}
+
+	if config.MaxFieldValueLength > 0 {
+		log.Hooks.Add(&maxFieldValueLengthHook{max: config.MaxFieldValueLength})
+	}
+
+	if logger.errOutput != nil {
+		log.Hooks.Add(logger.errOutput)
+	}
+
+	if config.PagerDutyRoutingKey != "" {
+		log.Hooks.Add(newPagerDutyHook(config.PagerDutyRoutingKey))
+	}
+
 	if withBugsnag {
-		log.Hooks.Add(&bugsnagHook{})
+		hook := newBugsnagHook(config)
+		log.Hooks.Add(hook)
+		logger.bugsnag = hook
+		logger.onClose = append(logger.onClose, hook.Close)
 	}
 
-	return &Logger{log}
+	return logger
 }
 
+var (
+	errorClassFunc     atomic.Pointer[func(err error) string]
+	registerErrorClass sync.Once
+)
+
 func Init(config LoggingConfig) {
 	if nil == Log {
 		bugsnag.Configure(bugsnag.Configuration{
@@ -309,31 +1561,76 @@ func Init(config LoggingConfig) {
 			ProjectPackages:     config.BugsnagProjectPackages,
 			Logger:              stdlog.New(new(false, config).Writer(), "bugsnag: ", 0),
 		})
-		bugsnag.OnBeforeNotify(
-			func(event *bugsnag.Event, config *bugsnag.Configuration) error {
-				errClass := event.ErrorClass
-				count := 0
-				wrappedError := event.Error.Err
-				for {
-					if errClass != "*fmt.wrapError" {
-						break
-					}
-
-					wrappedError = errors.Unwrap(wrappedError)
-					if wrappedError != nil {
-						errClass = reflect.TypeOf(wrappedError).String()
-					} else {
-						break
-					}
-					count++
-					if count >= 11 {
-						stdlog.Printf("Failed to unwrap error %s %s %+v", event.ErrorClass, errClass, event.Error)
-						break
+		errorClass := config.ErrorClassFunc
+		if errorClass == nil {
+			errorClass = DefaultErrorClass
+		}
+		errorClassFunc.Store(&errorClass)
+		// Bugsnag callbacks cannot be removed, so after a Shutdown the
+		// callback is kept and picks up the new errorClassFunc.
+		registerErrorClass.Do(func() {
+			bugsnag.OnBeforeNotify(
+				func(event *bugsnag.Event, config *bugsnag.Configuration) error {
+					if errClass := errorClassOf(event.Error.Err); errClass != "" {
+						event.ErrorClass = errClass
 					}
-				}
-				event.ErrorClass = errClass
-				return nil
-			})
+					return nil
+				})
+		})
 		Log = new(true, config)
+		defaultLogger.Store(Log)
+		warnDeprecated.Store(config.Environment != "production")
+		if config.OutputFile != "" {
+			out, err := openOutput(config)
+			if err != nil {
+				Log.WithError(err).Warn("failed to open log output, logging to stderr")
+			} else {
+				Log.Out = out
+			}
+		}
+		if config.AsyncBufferSize > 0 {
+			Log.enableAsync(config.AsyncBufferSize, config.OnDrop)
+		}
+		if config.OTLPEndpoint != "" {
+			if err := Log.enableOTLP(config.OTLPEndpoint); err != nil {
+				Log.WithError(err).Warn("failed to set up OTLP log export")
+			}
+		}
+		if config.CloudWatchGroup != "" {
+			if err := Log.enableCloudWatch(config); err != nil {
+				Log.WithError(err).Warn("failed to set up CloudWatch log export")
+			}
+		}
+	}
+}
+
+// Shutdown drains and closes the outputs and exporters of Log, removes its
+// hooks and resets Log to nil so that a later Init sets up a fresh logger.
+// If ctx is done first, Log is still reset and ctx.Err() is returned while
+// closing carries on in the background.
+//
+// Assigning Log is not synchronized, so call Shutdown once nothing reads Log
+// anymore, or have such code use Default instead. Entries and loggers
+// derived from Log before Shutdown stay usable; their writes to a closed
+// async output are dropped.
+func Shutdown(ctx context.Context) error {
+	logger := Log
+	if logger == nil {
+		return nil
+	}
+	defaultLogger.Store(nil)
+	Log = nil
+
+	done := make(chan error, 1)
+	go func() {
+		err := logger.Close()
+		logger.ReplaceHooks(make(logrus.LevelHooks))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
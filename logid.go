@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"math/rand/v2"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logIDEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// logIDHook stamps every entry with a random log_id so that identical lines
+// can still be told apart.
+type logIDHook struct{}
+
+func (h *logIDHook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[FieldLogID]; !ok {
+		entry.Data[FieldLogID] = newLogID()
+	}
+	return nil
+}
+
+func (h *logIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// newLogID returns 8 base32 characters encoding 40 random bits. The global
+// math/rand/v2 source is safe for concurrent use and does not lock.
+func newLogID() string {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], rand.Uint64())
+	return logIDEncoding.EncodeToString(b[:5])
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludeLogID(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{IncludeLogID: true})
+	logger.Out = out
+	logger.Level = logrus.InfoLevel
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("same line")
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var line struct {
+			LogID string `json:"log_id"`
+		}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		assert.Len(t, line.LogID, 8)
+		assert.False(t, seen[line.LogID], "duplicate log_id %s", line.LogID)
+		seen[line.LogID] = true
+	}
+	assert.Len(t, seen, 1000)
+}
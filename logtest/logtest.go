@@ -0,0 +1,67 @@
+// Package logtest provides helpers for asserting on the output of the
+// logging package in tests.
+package logtest
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// TestingT is the subset of *testing.T used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type helper interface {
+	Helper()
+}
+
+// AssertSchema parses a single JSON log line and reports an error for every
+// field in schema that is missing or has a different kind. JSON numbers
+// match reflect.Float64, and integer kinds when the number is integral.
+// It returns whether the line matched.
+func AssertSchema(t TestingT, logJSON string, schema map[string]reflect.Kind) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(logJSON), &line); err != nil {
+		t.Errorf("log line is not valid JSON: %s\n%s", err, logJSON)
+		return false
+	}
+
+	ok := true
+	for field, kind := range schema {
+		value, found := line[field]
+		if !found {
+			t.Errorf("field %q is missing from log line\n%s", field, logJSON)
+			ok = false
+			continue
+		}
+		if actual := reflect.ValueOf(value).Kind(); !kindMatches(kind, value) {
+			t.Errorf("field %q has kind %s, expected %s\n%s", field, actual, kind, logJSON)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func kindMatches(expected reflect.Kind, value interface{}) bool {
+	actual := reflect.ValueOf(value).Kind()
+	if actual == expected {
+		return true
+	}
+	number, isNumber := value.(float64)
+	if !isNumber || number != math.Trunc(number) {
+		return false
+	}
+	switch expected {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return number >= 0
+	}
+	return false
+}
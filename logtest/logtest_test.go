@@ -0,0 +1,55 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockT struct {
+	errors []string
+}
+
+func (m *mockT) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
+
+const line = `{"level":"info","message":"hello","usr.id":10,"ratio":0.5,"ok":true,"changes":{"a":1}}`
+
+func TestAssertSchemaPasses(t *testing.T) {
+	m := &mockT{}
+	ok := AssertSchema(m, line, map[string]reflect.Kind{
+		"level":   reflect.String,
+		"message": reflect.String,
+		"usr.id":  reflect.Uint64,
+		"ratio":   reflect.Float64,
+		"ok":      reflect.Bool,
+		"changes": reflect.Map,
+	})
+	assert.True(t, ok)
+	assert.Empty(t, m.errors)
+}
+
+func TestAssertSchemaFails(t *testing.T) {
+	t.Run("missing field", func(t *testing.T) {
+		m := &mockT{}
+		assert.False(t, AssertSchema(m, line, map[string]reflect.Kind{"dd.trace_id": reflect.Uint64}))
+		assert.Len(t, m.errors, 1)
+		assert.Contains(t, m.errors[0], `"dd.trace_id" is missing`)
+	})
+	t.Run("wrong kind", func(t *testing.T) {
+		m := &mockT{}
+		assert.False(t, AssertSchema(m, line, map[string]reflect.Kind{
+			"message": reflect.Int,
+			"ratio":   reflect.Int64,
+		}))
+		assert.Len(t, m.errors, 2)
+	})
+	t.Run("invalid JSON", func(t *testing.T) {
+		m := &mockT{}
+		assert.False(t, AssertSchema(m, "not json", map[string]reflect.Kind{}))
+		assert.Len(t, m.errors, 1)
+	})
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogValuer is implemented by types that control their own log
+// representation, for example to leave out PII. Field values implementing it
+// are replaced by the result of LogValue before the entry is formatted or
+// reported to Bugsnag.
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// logValuerHook resolves LogValuer field values.
+type logValuerHook struct{}
+
+func (h *logValuerHook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		if valuer, ok := value.(LogValuer); ok {
+			entry.Data[key] = valuer.LogValue()
+		}
+	}
+	return nil
+}
+
+func (h *logValuerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// lazyValue is a LogValuer computing its value only when first resolved. It
+// wraps the func in a struct as logrus refuses func field values. It also
+// formats as its value, for loggers from WrapLogger, which have no hook
+// resolving LogValuers.
+type lazyValue struct {
+	once  sync.Once
+	fn    func() interface{}
+	value interface{}
+}
+
+func (v *lazyValue) LogValue() interface{} {
+	v.once.Do(func() { v.value = v.fn() })
+	return v.value
+}
+
+func (v *lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.LogValue())
+}
+
+func (v *lazyValue) String() string {
+	return fmt.Sprint(v.LogValue())
+}
+
+// WithLazyField sets field to the result of fn, calling fn only if the entry
+// is logged at an enabled level, for values that are expensive to compute.
+// fn runs at most once, even if the entry is logged again, and also runs for
+// entries SampleRate sampling drops.
+func (e *Entry) WithLazyField(field string, fn func() interface{}) *Entry {
+	return e.WithField(field, &lazyValue{fn: fn})
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bugsnag/bugsnag-go/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	ID    int
+	Email string
+}
+
+func (u testUser) LogValue() interface{} {
+	return map[string]interface{}{"id": u.ID, "email": "[redacted]"}
+}
+
+func TestLogValuer(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{})
+	logger.Out = logFile.in
+	logger.Level = logrus.DebugLevel
+
+	var reported bugsnag.MetaData
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(err error, rawData ...interface{}) error {
+			reported = rawData[0].(bugsnag.MetaData)
+			return nil
+		},
+		timeout: time.Second,
+	})
+
+	user := testUser{ID: 7, Email: "jane@example.com"}
+	logger.WithField("user", user).WithError(errors.New("signup failed")).Error("signup failed")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, `"user":{"email":"[redacted]","id":7}`)
+	assert.NotContains(t, logFileContent, "jane@example.com")
+	assert.Equal(t, map[string]interface{}{"id": 7, "email": "[redacted]"}, reported["metadata"]["user"])
+}
+
+func TestWithLazyField(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{})
+	logger.Out = logFile.in
+	logger.Level = logrus.InfoLevel
+
+	calls := 0
+	entry := logger.NewEntry().WithLazyField("payload", func() interface{} {
+		calls++
+		return "expensive"
+	})
+	entry.Debug("filtered")
+	assert.Equal(t, 0, calls)
+
+	entry.Info("logged")
+	entry.Info("logged again")
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, logFile.getLogFileContent(t), `"payload":"expensive"`)
+}
+
+func TestWithLazyFieldWrappedLogger(t *testing.T) {
+	for _, format := range []string{"json", "text"} {
+		t.Run(format, func(t *testing.T) {
+			logFile := newMockLogFile(t)
+			base := logrus.New()
+			base.Out = logFile.in
+			if format == "json" {
+				base.Formatter = &logrus.JSONFormatter{}
+			}
+			logger := WrapLogger(base)
+
+			logger.NewEntry().WithLazyField("payload", func() interface{} { return 42 }).Info("logged")
+			assert.Contains(t, logFile.getLogFileContent(t), map[string]string{
+				"json": `"payload":42`,
+				"text": "payload=42",
+			}[format])
+		})
+	}
+}
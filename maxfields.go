@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxFieldsHook warns once per call site about entries carrying more than
+// max fields, which usually means a base entry is being mutated and reused.
+type maxFieldsHook struct {
+	max    int
+	warned sync.Map
+}
+
+func (h *maxFieldsHook) Fire(entry *logrus.Entry) error {
+	if len(entry.Data) <= h.max {
+		return nil
+	}
+	caller := callerOutsideLogging()
+	if _, warned := h.warned.LoadOrStore(caller, true); warned {
+		return nil
+	}
+	logrus.NewEntry(entry.Logger).WithFields(logrus.Fields{
+		FieldCaller:     caller,
+		FieldFieldCount: len(entry.Data),
+		FieldMaxFields:  h.max,
+	}).Warn("log entry exceeds MaxFields")
+	return nil
+}
+
+func (h *maxFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// maxFieldValueLengthHook truncates string field values longer than max
+// bytes. It runs before the Bugsnag hook, so reports are truncated too.
+type maxFieldValueLengthHook struct {
+	max int
+}
+
+func (h *maxFieldValueLengthHook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		if s, ok := value.(string); ok && len(s) > h.max {
+			entry.Data[key] = truncateString(s, h.max)
+		}
+	}
+	return nil
+}
+
+func (h *maxFieldValueLengthHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bugsnag/bugsnag-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxFields(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{MaxFields: 3})
+	logger.Out = logFile.in
+
+	logger.NewEntry().WithUser(1).WithChannel("fcm").Info("under the limit")
+	for i := 0; i < 2; i++ {
+		logger.NewEntry().
+			WithUser(1).
+			WithChannel("fcm").
+			WithRelation("follows").
+			WithSessionID("sess").
+			Info("over the limit")
+	}
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Equal(t, 1, strings.Count(logFileContent, "log entry exceeds MaxFields"), "the warning should only be logged once per caller")
+	assert.Contains(t, logFileContent, `"caller":"`)
+	assert.Contains(t, logFileContent, "maxfields_test.go:")
+	assert.Contains(t, logFileContent, `"field_count":4`)
+	assert.Equal(t, 2, strings.Count(logFileContent, "over the limit"))
+}
+
+func TestMaxFieldValueLength(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{MaxFieldValueLength: 8})
+	logger.Out = logFile.in
+
+	var reported bugsnag.MetaData
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(err error, rawData ...interface{}) error {
+			reported = rawData[0].(bugsnag.MetaData)
+			return nil
+		},
+		timeout: time.Second,
+	})
+
+	stack := strings.Repeat("frame\n", 10)
+	logger.WithField("stack", stack).WithField("short", "kept").Error("crashed")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.Contains(t, logFileContent, `"stack":"frame\nfr…[truncated 52 bytes]"`)
+	assert.Contains(t, logFileContent, `"short":"kept"`)
+	assert.Equal(t, "frame\nfr…[truncated 52 bytes]", reported["metadata"]["stack"])
+}
@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+)
+
+// NewRecoveryMiddleware wraps next so that a panicking handler is logged
+// through logger at Error level, reporting it to Bugsnag, and answered with
+// a 500 instead of taking the connection down. The entry carries the
+// request's context fields, method, route, path, client platform and
+// version and the stack of the panic.
+// http.ErrAbortHandler is re-panicked, as net/http expects. A nil logger
+// uses Default at the time of the panic; without one the panic is only
+// answered.
+func NewRecoveryMiddleware(next http.Handler, logger *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &headerRecorder{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
+			}
+			if l := orDefault(logger); l != nil {
+				l.WithClientFromHeaders(r.Header).
+					WithContext(r.Context()).
+					WithHTTPMethod(r.Method).
+					WithRouteFromRequest(r).
+					WithURL(FieldHTTPURL, r.URL).
+					WithField(FieldStack, string(debug.Stack())).
+					WithError(err).
+					Error("panic serving request")
+			}
+			if !recorder.wroteHeader {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// NewRequestSummaryMiddleware wraps next so that every request is logged
+// through logger as a single Info line once it completes, summarising it
+// with a RequestAccumulator. The accumulator is attached to the request's
+// context, see RequestAccumulatorFromContext, so handlers can add fields and
+// counters to the summary. The summary carries the request's context fields,
+// method, route, path, client platform and version, status code and
+// duration. A panicking handler is summarised with a 500 and the panic is
+// passed on, e.g. to NewRecoveryMiddleware wrapping this one. A nil logger
+// uses Default; without one requests are served unsummarised.
+func NewRequestSummaryMiddleware(next http.Handler, logger *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := orDefault(logger)
+		if l == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		accumulator := l.WithClientFromHeaders(r.Header).
+			WithContext(r.Context()).
+			WithHTTPMethod(r.Method).
+			WithURL(FieldHTTPURL, r.URL).
+			NewRequestAccumulator()
+		r = r.WithContext(ContextWithRequestAccumulator(r.Context(), accumulator))
+		recorder := &headerRecorder{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			status := recorder.statusCode()
+			if recovered != nil {
+				status = http.StatusInternalServerError
+			}
+			accumulator.With(func(e *Entry) *Entry {
+				return e.WithRouteFromRequest(r).withStatusCode(status)
+			})
+			accumulator.Finish("request finished")
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// headerRecorder records whether a response has been started, after which
+// the status can no longer be changed, and its status. It implements
+// http.Flusher and http.Hijacker so that streaming and websocket handlers
+// keep working.
+type headerRecorder struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+}
+
+func (r *headerRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+	}
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *headerRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(p)
+}
+
+// statusCode returns the status sent, which is 200 unless the handler set
+// another.
+func (r *headerRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *headerRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *headerRecorder) Flush() {
+	r.wroteHeader = true
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack returns http.ErrNotSupported if the underlying writer cannot be
+// hijacked.
+func (r *headerRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
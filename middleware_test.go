@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /catches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("nil catch"))
+	})
+	handler := NewRecoveryMiddleware(mux, nil)
+
+	t.Run("panic is logged and answered with a 500", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/catches/42?token=abc", nil)
+		request.Header.Set("X-Client-Platform", "ios")
+		request.Header.Set("X-Client-Version", "7.3.0")
+		handler.ServeHTTP(recorder, request)
+		logFileContent := logFile.getLogFileContent(t)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, logFileContent, `"level":"error"`)
+		assert.Contains(t, logFileContent, `"message":"panic serving request"`)
+		assert.Contains(t, logFileContent, `"error.message":"nil catch"`)
+		assert.Contains(t, logFileContent, `"http.method":"GET"`)
+		assert.Contains(t, logFileContent, `"http.route":"/catches/{id}"`)
+		assert.Contains(t, logFileContent, `"http.url":"/catches/42"`)
+		assert.Contains(t, logFileContent, `"client_platform":"ios"`)
+		assert.Contains(t, logFileContent, `"client_version":"7.3.0"`)
+		assert.Contains(t, logFileContent, `"stack":"goroutine`)
+		assert.Contains(t, logFileContent, "middleware_test.go")
+	})
+	t.Run("handlers that do not panic are untouched", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		ok := NewRecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}), nil)
+		recorder := httptest.NewRecorder()
+		ok.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/catches", nil))
+		logFileContent := logFile.getLogFileContent(t)
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Empty(t, logFileContent)
+	})
+	t.Run("ErrAbortHandler is re-panicked", func(t *testing.T) {
+		abort := NewRecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}), nil)
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
+
+func TestRecoveryMiddlewareWithoutLogger(t *testing.T) {
+	previous := defaultLogger.Swap(nil)
+	defer defaultLogger.Store(previous)
+
+	handler := NewRecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), nil)
+	recorder := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestRecoveryMiddlewareForwardsFlusherAndHijacker(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	var flushed, hijackable bool
+	handler := NewRecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijackable = w.(http.Hijacker)
+		if f, ok := w.(http.Flusher); ok {
+			w.Write([]byte("event: ping\n\n"))
+			f.Flush()
+			flushed = true
+		}
+	}), logger)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.True(t, flushed)
+	assert.True(t, recorder.Flushed)
+	assert.True(t, hijackable)
+}
+
+func TestRequestSummaryMiddleware(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	logger.Level = logrus.InfoLevel
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /catches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		RequestAccumulatorFromContext(r.Context()).Incr("db_queries")
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("GET /panics", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	handler := NewRecoveryMiddleware(NewRequestSummaryMiddleware(mux, logger), logger)
+
+	t.Run("summary is logged on completion", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		logger.Out = logFile.in
+
+		request := httptest.NewRequest(http.MethodPost, "/catches/42?token=abc", nil)
+		request.Header.Set("X-Client-Platform", "ios")
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		logFileContent := logFile.getLogFileContent(t)
+
+		assert.Equal(t, 1, strings.Count(logFileContent, "\n"), "expected a single summary line")
+		assert.Contains(t, logFileContent, `"message":"request finished"`)
+		assert.Contains(t, logFileContent, `"http.method":"POST"`)
+		assert.Contains(t, logFileContent, `"http.route":"/catches/{id}"`)
+		assert.Contains(t, logFileContent, `"http.url":"/catches/42"`)
+		assert.Contains(t, logFileContent, `"http.status_code":"201"`)
+		assert.Contains(t, logFileContent, `"client_platform":"ios"`)
+		assert.Contains(t, logFileContent, `"counts":{"db_queries":1}`)
+		assert.Contains(t, logFileContent, `"duration":`)
+	})
+	t.Run("panics are summarised and passed on", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		logger.Out = logFile.in
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panics", nil))
+		logFileContent := logFile.getLogFileContent(t)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Contains(t, logFileContent, `"message":"request finished"`)
+		assert.Contains(t, logFileContent, `"http.status_code":"500"`)
+		assert.Contains(t, logFileContent, `"message":"panic serving request"`)
+	})
+}
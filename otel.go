@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+const otelScopeName = "github.com/fishbrain/logging-go"
+
+// otelHook mirrors every entry to an OpenTelemetry logger, mapping the
+// level to a severity, the message to the body and fields to attributes.
+type otelHook struct {
+	logger otellog.Logger
+}
+
+func newOTelHook(provider otellog.LoggerProvider) *otelHook {
+	return &otelHook{logger: provider.Logger(otelScopeName)}
+}
+
+func (h *otelHook) Fire(entry *logrus.Entry) error {
+	if isReplayed(entry) || isSampledOut(entry) {
+		return nil
+	}
+	var record otellog.Record
+	record.SetTimestamp(entry.Time)
+	record.SetObservedTimestamp(clock())
+	record.SetSeverity(otelSeverity(entry.Level))
+	record.SetSeverityText(entry.Level.String())
+	record.SetBody(otellog.StringValue(entry.Message))
+	for key, value := range entry.Data {
+		record.AddAttributes(otellog.KeyValue{Key: key, Value: otelValue(value)})
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+func (h *otelHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func otelSeverity(level logrus.Level) otellog.Severity {
+	switch level {
+	case logrus.TraceLevel:
+		return otellog.SeverityTrace
+	case logrus.DebugLevel:
+		return otellog.SeverityDebug
+	case logrus.InfoLevel:
+		return otellog.SeverityInfo
+	case logrus.WarnLevel:
+		return otellog.SeverityWarn
+	case logrus.ErrorLevel:
+		return otellog.SeverityError
+	case logrus.FatalLevel:
+		return otellog.SeverityFatal
+	case logrus.PanicLevel:
+		return otellog.SeverityFatal4
+	}
+	return otellog.SeverityUndefined
+}
+
+func otelValue(value interface{}) otellog.Value {
+	switch v := value.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int:
+		return otellog.IntValue(v)
+	case int32:
+		return otellog.Int64Value(int64(v))
+	case int64:
+		return otellog.Int64Value(v)
+	case uint32:
+		return otellog.Int64Value(int64(v))
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return otellog.Int64Value(int64(v))
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return otellog.Int64Value(int64(v))
+		}
+	case float32:
+		return otellog.Float64Value(float64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case time.Time:
+		return otellog.StringValue(v.Format(time.RFC3339Nano))
+	case error:
+		return otellog.StringValue(v.Error())
+	case nil:
+		return otellog.Value{}
+	}
+	return otellog.StringValue(fmt.Sprintf("%v", value))
+}
+
+// enableOTLP exports every entry to the OTLP/HTTP collector at endpoint,
+// a URL such as http://otel-collector:4318. Buffered records are flushed
+// by Close.
+func (l *Logger) enableOTLP(endpoint string) error {
+	exporter, err := otlploghttp.New(context.Background(), otlploghttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return err
+	}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	l.Hooks.Add(newOTelHook(provider))
+	l.onClose = append(l.onClose, func() error {
+		return provider.Shutdown(context.Background())
+	})
+	return nil
+}
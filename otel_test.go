@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func TestOTelHook(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.Hooks.Add(newOTelHook(provider))
+
+	logger.NewEntry().WithUser(10).WithChannel("fcm").WithPercentage("progress", 0.5).Warn("slow delivery")
+
+	assert.Len(t, exporter.records, 1)
+	record := exporter.records[0]
+	assert.Equal(t, otellog.SeverityWarn, record.Severity())
+	assert.Equal(t, "warning", record.SeverityText())
+	assert.Equal(t, "slow delivery", record.Body().AsString())
+
+	attributes := map[string]otellog.Value{}
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attributes[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, int64(10), attributes["usr.id"].AsInt64())
+	assert.Equal(t, "fcm", attributes["channel"].AsString())
+	assert.Equal(t, 50.0, attributes["progress_pct"].AsFloat64())
+}
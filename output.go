@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultCompressFlushInterval = time.Second
+
+// compressedWriter gzips everything written to it into file. The gzip stream
+// is flushed periodically so that at most one interval of output is lost if
+// the process is killed without Close.
+type compressedWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	gz   *gzip.Writer
+	done chan struct{}
+}
+
+func newCompressedWriter(file *os.File, flushInterval time.Duration) *compressedWriter {
+	w := &compressedWriter{
+		file: file,
+		gz:   gzip.NewWriter(file),
+		done: make(chan struct{}),
+	}
+	go w.flushPeriodically(flushInterval)
+	return w
+}
+
+func (w *compressedWriter) flushPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *compressedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.gz.Write(p)
+}
+
+func (w *compressedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.gz.Flush()
+}
+
+func (w *compressedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	default:
+		close(w.done)
+	}
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// openOutput opens config.OutputFile for appending, wrapping it in a gzip
+// stream when config.CompressOutput is set.
+func openOutput(config LoggingConfig) (io.Writer, error) {
+	file, err := os.OpenFile(config.OutputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if config.CompressOutput {
+		return newCompressedWriter(file, defaultCompressFlushInterval), nil
+	}
+	return file, nil
+}
+
+// SetOutput swaps the output under the lock logrus holds while writing, so it
+// is safe to call while other goroutines log, e.g. to reopen a file after
+// logrotate sends SIGHUP. Once it returns no write to the previous output is
+// in flight; the previous output is flushed if it buffers and may be closed.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.outputMu.Lock()
+	defer l.outputMu.Unlock()
+	prev := l.Out
+	l.Logger.SetOutput(w)
+	if f, ok := prev.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+}
+
+// formattedOutputHook writes entries at levels, or every entry when levels
+// is nil, to out in its own format.
+type formattedOutputHook struct {
+	mu        sync.Mutex
+	out       io.Writer
+	formatter logrus.Formatter
+	levels    []logrus.Level
+}
+
+func (h *formattedOutputHook) Fire(entry *logrus.Entry) error {
+	if isSampledOut(entry) {
+		return nil
+	}
+	// Hooks get an entry without a buffer, so lend the formatter one.
+	if entry.Buffer == nil {
+		buf := getBuffer()
+		entry.Buffer = buf
+		defer func() {
+			entry.Buffer = nil
+			putBuffer(buf)
+		}()
+	}
+	serialized, err := h.formatter.Format(entry)
+	if err != nil || len(serialized) == 0 {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.out.Write(serialized)
+	return err
+}
+
+func (h *formattedOutputHook) Levels() []logrus.Level {
+	if h.levels != nil {
+		return h.levels
+	}
+	return logrus.AllLevels
+}
+
+// flush flushes out, if it buffers.
+func (h *formattedOutputHook) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if f, ok := h.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// close closes out, unless it is stdout or stderr.
+func (h *formattedOutputHook) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.out == os.Stdout || h.out == os.Stderr {
+		return nil
+	}
+	if c, ok := h.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// errorLevels are the levels written to ErrorOutput by SplitOutputByLevel.
+var errorLevels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+
+// entryFormatter returns the formatter of l as it is before Error entries
+// are split off to ErrorOutput, for sinks that want every entry.
+func (l *Logger) entryFormatter() logrus.Formatter {
+	if l.formatter != nil {
+		return l.formatter
+	}
+	return l.Formatter
+}
+
+// AddFormattedOutput also writes every entry to w, formatted by f, e.g. to
+// dual-write a new format during a migration. Entries have been through the
+// hooks registered so far. w is neither flushed nor closed by l.
+func (l *Logger) AddFormattedOutput(w io.Writer, f logrus.Formatter) {
+	l.AddHook(&formattedOutputHook{out: w, formatter: f})
+}
+
+// Flush flushes buffered output, including ErrorOutput, if the outputs
+// buffer at all.
+func (l *Logger) Flush() error {
+	var errs []error
+	if l.errOutput != nil {
+		errs = append(errs, l.errOutput.flush())
+	}
+	if f, ok := l.Out.(interface{ Flush() error }); ok {
+		errs = append(errs, f.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes the current output and any exporters. Stdout
+// and stderr are left open.
+func (l *Logger) Close() error {
+	var errs []error
+	for _, closeFn := range l.onClose {
+		errs = append(errs, closeFn())
+	}
+	l.onClose = nil
+	if l.errOutput != nil {
+		errs = append(errs, l.errOutput.close())
+	}
+	if l.Out != os.Stdout && l.Out != os.Stderr {
+		if c, ok := l.Out.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
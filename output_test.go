@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedOutput(t *testing.T) {
+	defer Log.Snapshot()()
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	out, err := openOutput(LoggingConfig{OutputFile: path, CompressOutput: true})
+	assert.NoError(t, err)
+
+	prevOut := Log.Out
+	defer func() { Log.Out = prevOut }()
+	Log.Out = out
+	Log.Level = logrus.InfoLevel
+
+	Log.Info("compressed line")
+	assert.NoError(t, Log.Flush())
+	assert.NoError(t, Log.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"message":"compressed line"`)
+}
+
+func TestCompressedOutputFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	out, err := openOutput(LoggingConfig{OutputFile: path, CompressOutput: true})
+	assert.NoError(t, err)
+	defer out.(io.Closer).Close()
+
+	_, err = out.Write([]byte("flushed line\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, out.(*compressedWriter).Flush())
+
+	// Without Close the gzip stream has no trailer, but flushed data must
+	// already be readable.
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	assert.NoError(t, err)
+	buf := make([]byte, len("flushed line\n"))
+	_, err = io.ReadFull(gz, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "flushed line\n", string(buf))
+}
+
+func TestSetOutputConcurrent(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	outputs := make([]*bytes.Buffer, 20)
+	for i := range outputs {
+		outputs[i] = &bytes.Buffer{}
+	}
+	logger.SetOutput(outputs[0])
+
+	const goroutines, linesPerGoroutine = 8, 200
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < linesPerGoroutine; i++ {
+				logger.WithField("goroutine", g).Infof("line %d", i)
+			}
+		}(g)
+	}
+	for _, out := range outputs[1:] {
+		logger.SetOutput(out)
+	}
+	wg.Wait()
+
+	lines := 0
+	for _, out := range outputs {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line), "corrupted line %q", scanner.Text())
+			lines++
+		}
+	}
+	assert.Equal(t, goroutines*linesPerGoroutine, lines)
+}
+
+func TestAddFormattedOutput(t *testing.T) {
+	legacy, text := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := new(false, LoggingConfig{})
+	logger.Out = legacy
+	logger.Level = logrus.InfoLevel
+	logger.AddFormattedOutput(text, &logrus.TextFormatter{DisableTimestamp: true})
+
+	logger.WithField("usr.id", 10).Info("dual written")
+	logger.Debug("filtered by level")
+
+	assert.Contains(t, legacy.String(), `"message":"dual written"`)
+	assert.Contains(t, legacy.String(), `"usr.id":10`)
+	assert.Equal(t, "level=info msg=\"dual written\" usr.id=10\n", text.String())
+}
+
+func BenchmarkAddFormattedOutput(b *testing.B) {
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.AddFormattedOutput(io.Discard, &logrus.JSONFormatter{})
+	entry := logger.WithField("usr.id", 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Info("benchmark")
+	}
+}
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pagerDutyEventsURL        = "https://events.pagerduty.com/v2/enqueue"
+	defaultPagerDutyInterval  = time.Minute
+	defaultPagerDutyTimeout   = 5 * time.Second
+	pagerDutyMaxSummaryLength = 1024
+)
+
+// pagerDutyHook triggers a PagerDuty incident for Fatal and Panic entries.
+// At most one event is sent per interval so a crash loop does not open an
+// incident storm; PagerDuty further groups events by their dedup key.
+type pagerDutyHook struct {
+	routingKey string
+	url        string
+	client     *http.Client
+	interval   time.Duration
+	source     string
+
+	mu       sync.Mutex
+	lastSent time.Time
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+func newPagerDutyHook(routingKey string) *pagerDutyHook {
+	source, err := os.Hostname()
+	if err != nil {
+		source = "unknown"
+	}
+	return &pagerDutyHook{
+		routingKey: routingKey,
+		url:        pagerDutyEventsURL,
+		client:     &http.Client{Timeout: defaultPagerDutyTimeout},
+		interval:   defaultPagerDutyInterval,
+		source:     source,
+	}
+}
+
+func (h *pagerDutyHook) Fire(entry *logrus.Entry) (err error) {
+	if isReplayed(entry) {
+		return nil
+	}
+	previous, sent, ok := h.reserve()
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if err != nil {
+			h.release(previous, sent)
+		}
+	}()
+
+	details := make(map[string]interface{}, len(entry.Data))
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		details[key] = value
+	}
+	summary := entry.Message
+	if len(summary) > pagerDutyMaxSummaryLength {
+		summary = summary[:pagerDutyMaxSummaryLength]
+	}
+	dedup := sha256.Sum256([]byte(entry.Message))
+	body, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  h.routingKey,
+		EventAction: "trigger",
+		DedupKey:    hex.EncodeToString(dedup[:16]),
+		Payload: pagerDutyPayload{
+			Summary:       summary,
+			Source:        h.source,
+			Severity:      "critical",
+			Timestamp:     entry.Time.Format(time.RFC3339Nano),
+			CustomDetails: details,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fatal exits right after the hooks run, so the event is sent inline.
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty responded %s", resp.Status)
+	}
+	return nil
+}
+
+// reserve takes the slot of the current interval, if it is free, and
+// returns the time of the previous send so release can give it back.
+func (h *pagerDutyHook) reserve() (previous, sent time.Time, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := clock()
+	if !h.lastSent.IsZero() && now.Sub(h.lastSent) < h.interval {
+		return time.Time{}, time.Time{}, false
+	}
+	previous, h.lastSent = h.lastSent, now
+	return previous, now, true
+}
+
+// release gives back a slot taken by reserve whose event wasn't delivered,
+// so the next Fatal or Panic entry is sent rather than rate limited.
+func (h *pagerDutyHook) release(previous, sent time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.lastSent.Equal(sent) {
+		h.lastSent = previous
+	}
+}
+
+func (h *pagerDutyHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel}
+}
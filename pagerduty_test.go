@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagerDutyHook(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.ExitFunc = func(int) {}
+	hook := newPagerDutyHook("routing-key")
+	hook.url = server.URL
+	logger.Hooks.Add(hook)
+
+	logger.Error("not paged")
+	logger.WithField("error.message", errors.New("disk full")).Fatal("cannot write checkpoint")
+	logger.Fatal("storm")
+
+	if assert.Len(t, events, 1, "events are rate limited") {
+		event := events[0]
+		assert.Equal(t, "routing-key", event.RoutingKey)
+		assert.Equal(t, "trigger", event.EventAction)
+		assert.NotEmpty(t, event.DedupKey)
+		assert.Equal(t, "cannot write checkpoint", event.Payload.Summary)
+		assert.Equal(t, "critical", event.Payload.Severity)
+		assert.Equal(t, "disk full", event.Payload.CustomDetails["error.message"])
+	}
+
+	now = now.Add(time.Minute)
+	logger.Fatal("cannot write checkpoint")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, events[0].DedupKey, events[1].DedupKey)
+	}
+}
+
+func TestPagerDutyHookFailedSendIsNotRateLimited(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	var events []pagerDutyEvent
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hook := newPagerDutyHook("routing-key")
+	hook.url = server.URL
+	entry := &logrus.Entry{Level: logrus.FatalLevel, Time: now, Message: "cannot start"}
+
+	assert.Error(t, hook.Fire(entry))
+	status = http.StatusAccepted
+	assert.NoError(t, hook.Fire(entry), "a failed send doesn't use up the interval")
+	assert.NoError(t, hook.Fire(entry))
+	assert.Len(t, events, 2, "a delivered event does")
+}
+
+func TestPagerDutyHookFromConfig(t *testing.T) {
+	logger := new(false, LoggingConfig{PagerDutyRoutingKey: "routing-key"})
+	var found bool
+	for _, hook := range logger.Hooks[logrus.FatalLevel] {
+		_, found = hook.(*pagerDutyHook)
+		if found {
+			break
+		}
+	}
+	assert.True(t, found)
+	for _, hook := range logger.Hooks[logrus.ErrorLevel] {
+		_, isPagerDuty := hook.(*pagerDutyHook)
+		assert.False(t, isPagerDuty, "Error entries should not page")
+	}
+}
@@ -0,0 +1,159 @@
+package logging
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	bugsnag_errors "github.com/bugsnag/bugsnag-go/v2/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Only international numbers, starting with +, are matched, so IDs,
+	// dates and other digit runs are left alone. National numbers such as
+	// 070-123 45 89 are not masked.
+	phonePattern = regexp.MustCompile(`\+\d{1,3}(?:[\s.\-]?\(?\d{1,4}\)?){2,5}`)
+)
+
+// maxScrubDepth bounds how deep piiScrubHook looks into nested slices and
+// maps, which may refer to themselves.
+const maxScrubDepth = 10
+
+// piiScrubHook masks email addresses and phone numbers in the message,
+// string fields, errors and fmt.Stringer fields of every entry, including
+// those nested in []string, []interface{} and string keyed maps.
+type piiScrubHook struct{}
+
+func (h *piiScrubHook) Fire(entry *logrus.Entry) error {
+	entry.Message = scrubPII(entry.Message)
+	for key, value := range entry.Data {
+		if scrubbed, ok := scrubValue(value, 0); ok {
+			entry.Data[key] = scrubbed
+		}
+	}
+	return nil
+}
+
+// scrubValue returns value with PII masked and whether anything was masked.
+// Slices and maps are shared with the code that logged them, so they are
+// copied rather than modified.
+func scrubValue(value interface{}, depth int) (interface{}, bool) {
+	if depth > maxScrubDepth {
+		return value, false
+	}
+	switch value := value.(type) {
+	case string:
+		scrubbed := scrubPII(value)
+		return scrubbed, scrubbed != value
+	case error:
+		return scrubError(value)
+	case fmt.Stringer:
+		s := value.String()
+		scrubbed := scrubPII(s)
+		return scrubbed, scrubbed != s
+	case []string:
+		return scrubSlice(value, func(s string) (string, bool) {
+			scrubbed := scrubPII(s)
+			return scrubbed, scrubbed != s
+		})
+	case []interface{}:
+		return scrubSlice(value, func(v interface{}) (interface{}, bool) { return scrubValue(v, depth+1) })
+	case map[string]string:
+		return scrubMap(value, func(s string) (string, bool) {
+			scrubbed := scrubPII(s)
+			return scrubbed, scrubbed != s
+		})
+	case map[string]interface{}:
+		return scrubMap(value, func(v interface{}) (interface{}, bool) { return scrubValue(v, depth+1) })
+	}
+	return value, false
+}
+
+func scrubSlice[T any](values []T, scrub func(T) (T, bool)) ([]T, bool) {
+	var scrubbed []T
+	for i, value := range values {
+		if masked, ok := scrub(value); ok {
+			if scrubbed == nil {
+				scrubbed = slices.Clone(values)
+			}
+			scrubbed[i] = masked
+		}
+	}
+	if scrubbed == nil {
+		return values, false
+	}
+	return scrubbed, true
+}
+
+func scrubMap[T any](values map[string]T, scrub func(T) (T, bool)) (map[string]T, bool) {
+	var scrubbed map[string]T
+	for key, value := range values {
+		if masked, ok := scrub(value); ok {
+			if scrubbed == nil {
+				scrubbed = maps.Clone(values)
+			}
+			scrubbed[key] = masked
+		}
+	}
+	if scrubbed == nil {
+		return values, false
+	}
+	return scrubbed, true
+}
+
+// scrubbedError is an error whose message had PII masked. It unwraps to the
+// original error so errors.Is, errors.As and error classes still work.
+type scrubbedError struct {
+	msg     string
+	err     error
+	callers []uintptr
+}
+
+func (e *scrubbedError) Error() string      { return e.msg }
+func (e *scrubbedError) Unwrap() error      { return e.err }
+func (e *scrubbedError) Callers() []uintptr { return e.callers }
+
+// scrubError returns err with PII masked from its message, keeping the
+// stack of bugsnag errors, and whether anything was masked.
+func scrubError(err error) (error, bool) {
+	msg := err.Error()
+	scrubbed := scrubPII(msg)
+	if scrubbed == msg {
+		return err, false
+	}
+	if bugsnagErr, ok := err.(*bugsnag_errors.Error); ok {
+		return bugsnag_errors.New(&scrubbedError{msg: scrubbed, err: bugsnagErr.Err, callers: bugsnagErr.Callers()}, 0), true
+	}
+	return &scrubbedError{msg: scrubbed, err: err}, true
+}
+
+func (h *piiScrubHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func scrubPII(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, maskEmail)
+	return phonePattern.ReplaceAllStringFunc(s, maskPhone)
+}
+
+// maskEmail keeps the first character of the local part and the domain,
+// e.g. j***@example.com.
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	return email[:1] + "***" + email[at:]
+}
+
+// maskPhone keeps only the last two digits, e.g. +***89.
+func maskPhone(phone string) string {
+	digits := make([]byte, 0, len(phone))
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+	return "+***" + string(digits[len(digits)-2:])
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	bugsnag_errors "github.com/bugsnag/bugsnag-go/v2/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubPII(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{ScrubPII: true})
+	logger.Out = logFile.in
+
+	logger.NewEntry().
+		WithField("contact", "reach me at jane.doe@example.com or +46 70 123 45 89").
+		WithField("catch_id", "2024-01-01-123456").
+		Info("signup by john@example.org, phone +1 (555) 010-9967")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.NotContains(t, logFileContent, "jane.doe@example.com")
+	assert.NotContains(t, logFileContent, "john@example.org")
+	assert.NotContains(t, logFileContent, "123 45 89")
+	assert.NotContains(t, logFileContent, "010-9967")
+	assert.Contains(t, logFileContent, `"contact":"reach me at j***@example.com or +***89"`)
+	assert.Contains(t, logFileContent, `"message":"signup by j***@example.org, phone +***67"`)
+	assert.Contains(t, logFileContent, `"catch_id":"2024-01-01-123456"`)
+}
+
+func TestScrubPIIErrors(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{ScrubPII: true})
+	logger.Out = logFile.in
+
+	notFound := &notFoundError{errors.New("no user jane.doe@example.com")}
+	bugsnagErr := bugsnag_errors.New(notFound, 0)
+	entry := logger.NewEntry().
+		WithField("cause", errors.New("call +46 70 123 45 89 failed")).
+		WithField("profile", &url.URL{Scheme: "mailto", Opaque: "john@example.org"})
+	entry.Data["error"] = bugsnagErr
+	entry.Info("lookup failed")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.NotContains(t, logFileContent, "jane.doe@example.com")
+	assert.NotContains(t, logFileContent, "123 45 89")
+	assert.NotContains(t, logFileContent, "john@example.org")
+	assert.Contains(t, logFileContent, `"error":"not found: no user j***@example.com"`)
+	assert.Contains(t, logFileContent, `"cause":"call +***89 failed"`)
+	assert.Contains(t, logFileContent, `"profile":"mailto:j***@example.org"`)
+
+	masked, ok := scrubError(bugsnagErr)
+	assert.True(t, ok)
+	scrubbed := masked.(*bugsnag_errors.Error)
+	assert.Equal(t, bugsnagErr.Callers(), scrubbed.Callers(), "the stack is kept")
+	assert.ErrorIs(t, scrubbed, notFound)
+	assert.Equal(t, "*logging.notFoundError", DefaultErrorClass(scrubbed))
+
+	plain := errors.New("nothing to mask")
+	unchanged, ok := scrubError(plain)
+	assert.False(t, ok)
+	assert.Same(t, plain, unchanged)
+}
+
+func TestScrubPIINestedValues(t *testing.T) {
+	logFile := newMockLogFile(t)
+	logger := new(false, LoggingConfig{ScrubPII: true})
+	logger.Out = logFile.in
+
+	tags := []interface{}{"owner jane.doe@example.com", 7}
+	entry := logger.NewEntry().
+		WithErrors([]error{errors.New("no user jane.doe@example.com"), errors.New("retry later")}).
+		WithChange("email", "jane.doe@example.com", "john@example.org").
+		WithField("tags", tags)
+	changes := entry.Data[FieldChanges].(map[string]interface{})
+	entry.Info("profile updated")
+	logFileContent := logFile.getLogFileContent(t)
+
+	assert.NotContains(t, logFileContent, "jane.doe@example.com")
+	assert.NotContains(t, logFileContent, "john@example.org")
+	assert.Contains(t, logFileContent, `"errors":["no user j***@example.com","retry later"]`)
+	assert.Contains(t, logFileContent, `"changes":{"email":{"after":"j***@example.org","before":"j***@example.com"}}`)
+	assert.Contains(t, logFileContent, `"tags":["owner j***@example.com",7]`)
+
+	assert.Equal(t, "owner jane.doe@example.com", tags[0], "the logged values are not modified")
+	assert.Equal(t, "jane.doe@example.com", changes["email"].(map[string]interface{})["before"])
+}
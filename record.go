@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type jsonRecord struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// MarshalRecord serializes the level, message, time and fields of e so it
+// can be replayed elsewhere with ReplayRecord. Error values are stored as
+// their message.
+func (e *Entry) MarshalRecord() ([]byte, error) {
+	fields := make(map[string]interface{}, len(e.Data))
+	for key, value := range e.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[key] = value
+	}
+	return json.Marshal(jsonRecord{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Message: e.Message,
+		Fields:  fields,
+	})
+}
+
+// ReplayRecord logs a record produced by MarshalRecord through l, keeping
+// its original time. Panic and Fatal records are replayed at Error level,
+// with the original level under replayed_level, so replaying never panics
+// or exits.
+func ReplayRecord(l *Logger, data []byte) error {
+	var record jsonRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	return replay(l, record)
+}
+
+func replay(l *Logger, record jsonRecord) error {
+	entry, level, err := recordEntry(l, record)
+	if err != nil {
+		return err
+	}
+	if level < logrus.ErrorLevel {
+		entry = entry.WithField(FieldReplayedLevel, level.String())
+		level = logrus.ErrorLevel
+	}
+	entry.Log(level, record.Message)
+	return nil
+}
+
+func recordEntry(l *Logger, record jsonRecord) (*logrus.Entry, logrus.Level, error) {
+	level, err := logrus.ParseLevel(record.Level)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := l.NewEntry().Entry.WithFields(record.Fields)
+	if !record.Time.IsZero() {
+		entry = entry.WithTime(record.Time)
+	}
+	return entry, level, nil
+}
+
+// maxMalformedLines caps the line numbers kept by a MalformedLinesError.
+const maxMalformedLines = 100
+
+// MalformedLinesError is returned by ReplayFile when some lines could not be
+// replayed. Lines holds the numbers, from 1, of the first 100 of them and
+// Count how many there were in total.
+type MalformedLinesError struct {
+	Lines []int
+	Count int
+}
+
+func (e *MalformedLinesError) Error() string {
+	return fmt.Sprintf("skipped %d malformed lines, first at line %d", e.Count, e.Lines[0])
+}
+
+// replayedKey marks the context of entries logged by ReplayFile.
+type replayedKey struct{}
+
+// isReplayed reports whether entry is an archived line logged by
+// ReplayFile, which hooks sending entries to Bugsnag, PagerDuty, CloudWatch
+// or OTLP skip so archived entries aren't reported again.
+func isReplayed(entry *logrus.Entry) bool {
+	return entry.Context != nil && entry.Context.Value(replayedKey{}) != nil
+}
+
+// ReplayFile logs every line of the JSON lines file at path, as written by
+// the JSON output of this package, through l, e.g. to convert archived logs
+// to another format. The time, level and message keys are restored and
+// every other key becomes a field. Lines keep their original level and go
+// to the outputs of l, ErrorOutput included, but Bugsnag, PagerDuty,
+// CloudWatch and OTLP skip them; hooks added with AddHook see them. Replayed
+// Panic lines don't panic. Malformed lines are skipped and reported in a
+// *MalformedLinesError once the file is replayed.
+func ReplayFile(path string, l *Logger) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	malformed := &MalformedLinesError{}
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := replayLine(l, line); err != nil {
+				if malformed.Count < maxMalformedLines {
+					malformed.Lines = append(malformed.Lines, lineNumber)
+				}
+				malformed.Count++
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if malformed.Count > 0 {
+		return malformed
+	}
+	return nil
+}
+
+func replayLine(l *Logger, line []byte) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return err
+	}
+	record := jsonRecord{Fields: fields}
+	var ok bool
+	if record.Level, ok = fields["level"].(string); !ok {
+		return errors.New("missing level")
+	}
+	record.Message, _ = fields["message"].(string)
+	if ts, ok := fields["time"].(string); ok {
+		parsed, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return err
+		}
+		record.Time = parsed
+	}
+	delete(fields, "level")
+	delete(fields, "message")
+	delete(fields, "time")
+
+	entry, level, err := recordEntry(l, record)
+	if err != nil {
+		return err
+	}
+	if entry.Time.IsZero() {
+		entry = entry.WithTime(clock())
+	}
+	entry = entry.WithContext(context.WithValue(context.Background(), replayedKey{}, true))
+	if level == logrus.PanicLevel {
+		// logrus panics with the entry once a Panic entry is written.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if _, ok := recovered.(*logrus.Entry); !ok {
+					panic(recovered)
+				}
+			}
+		}()
+	}
+	entry.Log(level, record.Message)
+	return nil
+}
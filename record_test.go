@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// capturingHook keeps the last entry it was fired with.
+type capturingHook struct {
+	entry *logrus.Entry
+}
+
+func (h *capturingHook) Fire(entry *logrus.Entry) error {
+	h.entry = entry
+	return nil
+}
+
+func (h *capturingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	prod := new(false, LoggingConfig{})
+	prod.Out = io.Discard
+	hook := &capturingHook{}
+	prod.Hooks.Add(hook)
+
+	prod.NewEntry().WithUser(10).WithChannel("fcm").WithError(errors.New("boom")).Warn("delivery failed")
+	data, err := WrapEntry(hook.entry).MarshalRecord()
+	assert.NoError(t, err)
+
+	logFile := newMockLogFile(t)
+	dev := new(false, LoggingConfig{})
+	dev.Out = logFile.in
+	assert.NoError(t, ReplayRecord(dev, data))
+	logFileContent := logFile.getLogFileContent(t)
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(logFileContent), &line))
+	assert.Equal(t, "warning", line["level"])
+	assert.Equal(t, "delivery failed", line["message"])
+	assert.Equal(t, float64(10), line["usr.id"])
+	assert.Equal(t, "fcm", line["channel"])
+	assert.Equal(t, "boom", line["error.message"])
+	assert.Equal(t, hook.entry.Time.Format(time.RFC3339Nano), line["time"])
+}
+
+func TestReplayRecordPanicLevel(t *testing.T) {
+	logFile := newMockLogFile(t)
+	dev := new(false, LoggingConfig{})
+	dev.Out = logFile.in
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, ReplayRecord(dev, []byte(`{"level":"panic","message":"crashed"}`)))
+	})
+	logFileContent := logFile.getLogFileContent(t)
+	assert.Contains(t, logFileContent, `"level":"error"`)
+	assert.Contains(t, logFileContent, `"replayed_level":"panic"`)
+}
+
+func TestReplayRecordInvalid(t *testing.T) {
+	assert.Error(t, ReplayRecord(Log, []byte("not json")))
+	assert.Error(t, ReplayRecord(Log, []byte(`{"level":"loud"}`)))
+}
+
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.log")
+	fixture := `{"level":"warning","message":"delivery failed","time":"2024-01-01T12:00:00Z","usr.id":10,"error.message":"boom"}
+{"level":"info","message":"truncated
+`
+	assert.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{Format: "text"})
+	logger.Out = out
+	logger.Level = logrus.DebugLevel
+
+	err := ReplayFile(path, logger)
+	var malformed *MalformedLinesError
+	assert.ErrorAs(t, err, &malformed)
+	assert.Equal(t, []int{2}, malformed.Lines)
+	assert.Equal(t, 1, malformed.Count)
+
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+	assert.Contains(t, out.String(), "time=\"2024-01-01T12:00:00Z\"")
+	assert.Contains(t, out.String(), "level=warning")
+	assert.Contains(t, out.String(), `message="delivery failed"`)
+	assert.Contains(t, out.String(), "usr.id=10")
+	assert.Contains(t, out.String(), "error.message=boom")
+}
+
+func TestReplayFileSkipsNotifiers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	path := filepath.Join(t.TempDir(), "archive.log")
+	fixture := `{"level":"error","message":"payment failed"}
+{"level":"fatal","message":"crashed","time":"2023-06-01T00:00:00Z"}
+{"level":"panic","message":"panicked","time":"2023-06-01T00:00:00Z"}
+{"level":"info","message":"served"}
+`
+	assert.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := new(false, LoggingConfig{Format: "text", SplitOutputByLevel: true, ErrorOutput: errOut})
+	logger.Out = out
+	notified := 0
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(error, ...interface{}) error {
+			notified++
+			return nil
+		},
+		timeout: time.Second,
+	})
+
+	assert.NotPanics(t, func() { assert.NoError(t, ReplayFile(path, logger)) })
+	assert.Zero(t, notified, "archived entries are not reported to Bugsnag again")
+	assert.Contains(t, errOut.String(), `message="payment failed"`)
+	assert.Contains(t, errOut.String(), `time="2024-01-01T12:00:00Z"`, "lines without a time use the clock")
+	assert.Contains(t, errOut.String(), "level=fatal", "the original level is kept")
+	assert.Contains(t, errOut.String(), "level=panic")
+	assert.NotContains(t, out.String(), "payment failed", "Error lines go to ErrorOutput")
+	assert.Contains(t, out.String(), `message=served`)
+}
+
+func TestReplayFileCapsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.log")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("not json\n", 250)), 0o644))
+
+	err := ReplayFile(path, new(false, LoggingConfig{}))
+	var malformed *MalformedLinesError
+	assert.ErrorAs(t, err, &malformed)
+	assert.Len(t, malformed.Lines, maxMalformedLines)
+	assert.Equal(t, 250, malformed.Count)
+	assert.EqualError(t, err, "skipped 250 malformed lines, first at line 1")
+}
+
+func TestReplayFileMissing(t *testing.T) {
+	err := ReplayFile(filepath.Join(t.TempDir(), "missing.log"), new(false, LoggingConfig{}))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sampledOutKey marks the context of entries samplingHook dropped.
+type sampledOutKey struct{}
+
+// samplingHook keeps roughly rate of the entries and marks the rest as
+// sampled out, deciding once per entry so that every output and exporter
+// agrees. Entries at Error level and above and entries carrying an error
+// are always kept. Kept entries that could have been dropped are stamped
+// with sampled and sample_rate, so downstream can scale counts back up.
+// It must run before the hooks that export entries.
+type samplingHook struct {
+	rate   float64
+	random func() float64
+}
+
+func newSamplingHook(rate float64) *samplingHook {
+	return &samplingHook{rate: rate, random: rand.Float64}
+}
+
+func (h *samplingHook) Fire(entry *logrus.Entry) error {
+	if h.alwaysKeep(entry) {
+		return nil
+	}
+	if h.random() >= h.rate {
+		ctx := entry.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		entry.Context = context.WithValue(ctx, sampledOutKey{}, true)
+		return nil
+	}
+	entry.Data[FieldSampled] = true
+	entry.Data[FieldSampleRate] = h.rate
+	return nil
+}
+
+func (h *samplingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *samplingHook) alwaysKeep(entry *logrus.Entry) bool {
+	if entry.Level <= logrus.ErrorLevel {
+		return true
+	}
+	err, ok := entry.Data[logrus.ErrorKey]
+	return ok && err != nil
+}
+
+// isSampledOut reports whether samplingHook dropped entry.
+func isSampledOut(entry *logrus.Entry) bool {
+	return entry.Context != nil && entry.Context.Value(sampledOutKey{}) != nil
+}
+
+// samplingFormatter drops entries samplingHook sampled out by returning an
+// empty line.
+type samplingFormatter struct {
+	formatter logrus.Formatter
+}
+
+func (f *samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if isSampledOut(entry) {
+		return nil, nil
+	}
+	return f.formatter.Format(entry)
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+func TestSamplingKeepsErrors(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SampleRate: 0.01})
+	logger.Out = out
+	sampler := logger.sampler
+	sampler.random = func() float64 { return 0.5 }
+
+	for i := 0; i < 100; i++ {
+		logger.Info("sampled info")
+		logger.Error("kept error")
+		logger.NewEntry().WithError(errors.New("boom")).Warn("kept warning")
+	}
+
+	assert.Equal(t, 0, strings.Count(out.String(), "sampled info"))
+	assert.Equal(t, 100, strings.Count(out.String(), "kept error"))
+	assert.Equal(t, 100, strings.Count(out.String(), "kept warning"))
+}
+
+func TestSamplingRate(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SampleRate: 0.5})
+	logger.Out = out
+	sampler := logger.sampler
+	draws := 0
+	sampler.random = func() float64 {
+		draws++
+		if draws%2 == 0 {
+			return 0.75
+		}
+		return 0.25
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.Info("sampled info")
+	}
+	assert.Equal(t, 5, strings.Count(out.String(), "sampled info"))
+}
+
+func TestSamplingStampsSampledLines(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SampleRate: 0.25})
+	logger.Out = out
+	sampler := logger.sampler
+	sampler.random = func() float64 { return 0.1 }
+
+	entry := logger.WithField("usr.id", 10)
+	entry.Info("sampled info")
+	assert.NotContains(t, entry.Data, "sampled", "entry of the caller modified")
+	logger.Error("kept error")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"sampled":true`)
+	assert.Contains(t, lines[0], `"sample_rate":0.25`)
+	assert.Contains(t, lines[0], `"usr.id":10`)
+	assert.NotContains(t, lines[1], "sampled")
+	assert.NotContains(t, lines[1], "sample_rate")
+}
+
+func TestSamplingAppliesToEverySink(t *testing.T) {
+	out, dual := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := new(false, LoggingConfig{SampleRate: 0.5})
+	logger.Out = out
+	logger.AddFormattedOutput(dual, &logrus.TextFormatter{DisableTimestamp: true})
+	exporter := &memoryExporter{}
+	logger.Hooks.Add(newOTelHook(sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))))
+	draws := 0
+	logger.sampler.random = func() float64 {
+		draws++
+		if draws%2 == 0 {
+			return 0.75
+		}
+		return 0.25
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.Info("sampled info")
+	}
+	assert.Equal(t, 10, draws, "sampling is decided once per entry")
+	assert.Equal(t, 5, strings.Count(out.String(), "sampled info"))
+	assert.Equal(t, 5, strings.Count(dual.String(), "sampled info"))
+	assert.Equal(t, 5, strings.Count(dual.String(), "sampled=true"))
+	assert.Len(t, exporter.records, 5)
+}
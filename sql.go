@@ -0,0 +1,99 @@
+package logging
+
+import "strings"
+
+// WithSQLQuery adds query under sql_query with its string and numeric
+// literals replaced by ?, so the shape of a query can be logged without the
+// values in it. Quoted identifiers and $1 style placeholders are kept.
+// Queries are assumed to be PostgreSQL: "..." is an identifier, backslashes
+// only escape in E'...' strings and $$...$$ or $tag$...$tag$ are strings. In
+// MySQL, double-quoted and backslash-escaped strings may be logged as is.
+func (e *Entry) WithSQLQuery(query string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldSQLQuery, sanitizeSQL(query))
+}
+
+func sanitizeSQL(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		atWordStart := i == 0 || !isSQLIdentifierByte(query[i-1])
+		switch {
+		case c == '\'':
+			i = skipSQLString(query, i, false)
+			b.WriteByte('?')
+		case (c == 'E' || c == 'e') && atWordStart && i+1 < len(query) && query[i+1] == '\'':
+			i = skipSQLString(query, i+1, true)
+			b.WriteByte('?')
+		case c == '$' && atWordStart && dollarQuoteTag(query[i:]) != "":
+			tag := dollarQuoteTag(query[i:])
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += len(tag) + end + len(tag) - 1
+			}
+			b.WriteByte('?')
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case isSQLDigit(c) && atWordStart:
+			for i+1 < len(query) && (isSQLDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// skipSQLString returns the index of the quote closing the string literal
+// opened at start, or the end of query if it is unterminated. A doubled
+// quote escapes a quote, as does a backslash when backslash escapes are
+// enabled.
+func skipSQLString(query string, start int, backslashEscapes bool) int {
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case backslashEscapes && query[i] == '\\':
+			i++
+		case query[i] == '\'':
+			if i+1 < len(query) && query[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(query)
+}
+
+// dollarQuoteTag returns the $tag$ or $$ opening a dollar-quoted string at
+// the start of s, or "" if there is none, e.g. for a $1 placeholder.
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 1 && isSQLDigit(c)):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' || isSQLDigit(c) ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			"quoted strings",
+			`SELECT * FROM users WHERE email = 'jane@example.com' AND name = 'O''Brien'`,
+			`SELECT * FROM users WHERE email = ? AND name = ?`,
+		},
+		{
+			"numeric literals",
+			`UPDATE catches SET weight = 3.5 WHERE id IN (12, 345) LIMIT 10`,
+			`UPDATE catches SET weight = ? WHERE id IN (?, ?) LIMIT ?`,
+		},
+		{
+			"identifiers and placeholders are kept",
+			`SELECT t1.col2 FROM "table 3" t1 WHERE t1.id = $1`,
+			`SELECT t1.col2 FROM "table 3" t1 WHERE t1.id = $1`,
+		},
+		{
+			"escape strings",
+			`SELECT * FROM users WHERE name = E'O\'Brien' AND note = e'a\\' AND id = 1`,
+			`SELECT * FROM users WHERE name = ? AND note = ? AND id = ?`,
+		},
+		{
+			"backslashes in standard strings",
+			`SELECT * FROM files WHERE path = 'C:\' AND size = 10`,
+			`SELECT * FROM files WHERE path = ? AND size = ?`,
+		},
+		{
+			"dollar quoting",
+			`SELECT $$it's secret$$, $body$a $$ b$body$ WHERE id = $1 AND name = $n$x$n$`,
+			`SELECT ?, ? WHERE id = $1 AND name = ?`,
+		},
+		{
+			"unterminated dollar quoting",
+			`SELECT $tag$secret`,
+			`SELECT ?`,
+		},
+		{
+			"unterminated literals",
+			`SELECT 'unterminated`,
+			`SELECT ?`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, sanitizeSQL(test.query))
+		})
+	}
+}
+
+func TestWithSQLQuery(t *testing.T) {
+	defer Log.Snapshot()()
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	Log.NewEntry().WithSQLQuery(`SELECT * FROM users WHERE id = 42 AND token = 'secret'`).Warn("slow query")
+	logFileContent := logFile.getLogFileContent(t)
+	assert.Contains(t, logFileContent, `"sql_query":"SELECT * FROM users WHERE id = ? AND token = ?"`)
+	assert.NotContains(t, logFileContent, "secret")
+}
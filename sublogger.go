@@ -0,0 +1,63 @@
+package logging
+
+// SubLogger logs with a fixed set of base fields. Every log call starts from
+// a fresh copy of the base entry, so a SubLogger can be stored and shared
+// between goroutines.
+type SubLogger struct {
+	base *Entry
+}
+
+func (l *Logger) SubLogger() *SubLogger {
+	return l.NewEntry().SubLogger()
+}
+
+// SubLogger returns a SubLogger using the fields of e as its base.
+func (e *Entry) SubLogger() *SubLogger {
+	return &SubLogger{base: e}
+}
+
+// Entry returns a new entry carrying the base fields.
+func (s *SubLogger) Entry() *Entry {
+	return &Entry{s.base.Dup()}
+}
+
+// WithField returns a child SubLogger with field added to the base fields.
+func (s *SubLogger) WithField(field string, value interface{}) *SubLogger {
+	return &SubLogger{base: s.base.WithField(field, value)}
+}
+
+func (s *SubLogger) WithComponent(component string) *SubLogger {
+	return &SubLogger{base: s.base.WithComponent(component)}
+}
+
+func (s *SubLogger) Debug(args ...interface{}) {
+	s.Entry().Debug(args...)
+}
+
+func (s *SubLogger) Info(args ...interface{}) {
+	s.Entry().Info(args...)
+}
+
+func (s *SubLogger) Warn(args ...interface{}) {
+	s.Entry().Warn(args...)
+}
+
+func (s *SubLogger) Error(args ...interface{}) {
+	s.Entry().Error(args...)
+}
+
+func (s *SubLogger) Debugf(format string, args ...interface{}) {
+	s.Entry().Debugf(format, args...)
+}
+
+func (s *SubLogger) Infof(format string, args ...interface{}) {
+	s.Entry().Infof(format, args...)
+}
+
+func (s *SubLogger) Warnf(format string, args ...interface{}) {
+	s.Entry().Warnf(format, args...)
+}
+
+func (s *SubLogger) Errorf(format string, args ...interface{}) {
+	s.Entry().Errorf(format, args...)
+}
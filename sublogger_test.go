@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubLoggerConcurrent(t *testing.T) {
+	defer Log.Snapshot()()
+	logFile := newMockLogFile(t)
+	Log.Logger.Out = logFile.in
+	Log.Logger.Level = logrus.DebugLevel
+
+	base := Log.WithComponent("sync").SubLogger()
+	child := base.WithField("phase", "load")
+
+	wg := sync.WaitGroup{}
+	wg.Add(20)
+	for i := 0; i < 10; i++ {
+		go func(i int) { defer wg.Done(); base.Infof("base %d", i) }(i)
+		go func(i int) { defer wg.Done(); child.Entry().WithUser(uint64(i)).Info("child") }(i)
+	}
+	wg.Wait()
+
+	logFileContent := logFile.getLogFileContent(t)
+	baseLines, childLines := 0, 0
+	scanner := bufio.NewScanner(strings.NewReader(logFileContent))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		assert.Equal(t, "sync", line["component"])
+		if line["message"] == "child" {
+			childLines++
+			assert.Equal(t, "load", line["phase"])
+			assert.Contains(t, line, "usr.id")
+		} else {
+			baseLines++
+			assert.NotContains(t, line, "phase")
+			assert.NotContains(t, line, "usr.id")
+		}
+	}
+	assert.Equal(t, 10, baseLines)
+	assert.Equal(t, 10, childLines)
+}
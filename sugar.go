@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// withKeysAndValues adds alternating key/value pairs as fields. A trailing
+// key without a value is dropped with a warning.
+func (e *Entry) withKeysAndValues(keysAndValues []interface{}) *Entry {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields[key] = keysAndValues[i+1]
+	}
+	if len(keysAndValues)%2 != 0 {
+		e.WithField(FieldIgnoredKey, keysAndValues[len(keysAndValues)-1]).
+			Warn("odd number of keysAndValues, ignoring key without a value")
+	}
+	return &Entry{e.Entry.WithFields(fields)}
+}
+
+// Debugw logs msg at Debug level with keysAndValues as alternating
+// field keys and values.
+func (e *Entry) Debugw(msg string, keysAndValues ...interface{}) {
+	e.withKeysAndValues(keysAndValues).Debug(msg)
+}
+
+// Infow logs msg at Info level with keysAndValues as alternating
+// field keys and values.
+func (e *Entry) Infow(msg string, keysAndValues ...interface{}) {
+	e.withKeysAndValues(keysAndValues).Info(msg)
+}
+
+// Warnw logs msg at Warn level with keysAndValues as alternating
+// field keys and values.
+func (e *Entry) Warnw(msg string, keysAndValues ...interface{}) {
+	e.withKeysAndValues(keysAndValues).Warn(msg)
+}
+
+// Errorw logs msg at Error level with keysAndValues as alternating
+// field keys and values.
+func (e *Entry) Errorw(msg string, keysAndValues ...interface{}) {
+	e.withKeysAndValues(keysAndValues).Error(msg)
+}
+
+// StdLogger is the Print subset of the standard library's *log.Logger, as
+// accepted by many libraries. *Logger implements it at Info level.
+type StdLogger interface {
+	Print(args ...interface{})
+	Printf(format string, args ...interface{})
+	Println(args ...interface{})
+}
+
+var _ StdLogger = (*Logger)(nil)
+
+// Print logs at Info level, for code migrating from the log package.
+func (l *Logger) Print(args ...interface{}) {
+	l.NewEntry().Info(args...)
+}
+
+// Printf logs at Info level, for code migrating from the log package.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	l.NewEntry().Infof(format, args...)
+}
+
+// Println logs at Info level, for code migrating from the log package.
+func (l *Logger) Println(args ...interface{}) {
+	l.NewEntry().Infoln(args...)
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfow(t *testing.T) {
+	defer Log.Snapshot()()
+	Log.Logger.Level = logrus.DebugLevel
+
+	t.Run("even number of arguments", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().Infow("synced", "table", "catches", "rows", 3)
+		logFileContent := logFile.getLogFileContent(t)
+		assert.Contains(t, logFileContent, `"table":"catches"`)
+		assert.Contains(t, logFileContent, `"rows":3`)
+		assert.NotContains(t, logFileContent, "odd number")
+	})
+	t.Run("odd number of arguments", func(t *testing.T) {
+		logFile := newMockLogFile(t)
+		Log.Logger.Out = logFile.in
+
+		Log.NewEntry().Infow("synced", "table", "catches", "rows")
+		logFileContent := logFile.getLogFileContent(t)
+		lines := strings.Split(strings.TrimSpace(logFileContent), "\n")
+		assert.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"level":"warning"`)
+		assert.Contains(t, lines[0], `"ignored_key":"rows"`)
+		assert.Contains(t, lines[1], `"message":"synced"`)
+		assert.Contains(t, lines[1], `"table":"catches"`)
+		assert.NotContains(t, lines[1], "rows")
+	})
+}
+
+func TestStdLoggerShim(t *testing.T) {
+	logger := new(false, LoggingConfig{})
+	logger.Level = logrus.InfoLevel
+
+	var std StdLogger = logger
+	for name, logFn := range map[string]func(){
+		"Print":   func() { std.Print("migrated ", 1) },
+		"Printf":  func() { std.Printf("migrated %d", 1) },
+		"Println": func() { std.Println("migrated", 1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			logFile := newMockLogFile(t)
+			logger.Out = logFile.in
+
+			logFn()
+			logFileContent := logFile.getLogFileContent(t)
+			assert.Contains(t, logFileContent, `"level":"info"`)
+			assert.Contains(t, logFileContent, `"message":"migrated 1"`)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxThrottleKeys bounds the keys a throttle remembers. Past it the key let
+// through longest ago is forgotten, even if its interval hasn't passed.
+const maxThrottleKeys = 1024
+
+// throttle lets one call per key through every interval. It keys Bugsnag
+// notifies by the entry's throttle_key, or its error message when unset.
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	keys     map[string]*list.Element
+	// order holds a throttleKey per key, from the one let through longest
+	// ago, so expired keys are swept from its front.
+	order *list.List
+}
+
+type throttleKey struct {
+	key  string
+	last time.Time
+}
+
+func newThrottle(interval time.Duration) *throttle {
+	if interval <= 0 {
+		return nil
+	}
+	return &throttle{interval: interval, keys: map[string]*list.Element{}, order: list.New()}
+}
+
+// allow reports whether a call for key may go through. A nil throttle
+// always allows.
+func (t *throttle) allow(key string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := clock()
+	if element, ok := t.keys[key]; ok {
+		if now.Sub(element.Value.(*throttleKey).last) < t.interval {
+			return false
+		}
+		t.forget(element)
+	}
+	for front := t.order.Front(); front != nil; front = t.order.Front() {
+		if now.Sub(front.Value.(*throttleKey).last) < t.interval && t.order.Len() < maxThrottleKeys {
+			break
+		}
+		t.forget(front)
+	}
+	t.keys[key] = t.order.PushBack(&throttleKey{key: key, last: now})
+	return true
+}
+
+func (t *throttle) forget(element *list.Element) {
+	delete(t.keys, t.order.Remove(element).(*throttleKey).key)
+}
+
+// WithThrottleKey sets the key Bugsnag notifies are throttled by, when
+// BugsnagThrottleInterval is set, instead of the error message. Use it to
+// throttle per user or per job rather than per distinct message.
+func (e *Entry) WithThrottleKey(key string) *Entry {
+	return e.WithStringFieldIgnoreEmpty(FieldThrottleKey, key)
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBugsnagThrottleKeys(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	notified := 0
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(error, ...interface{}) error {
+			notified++
+			return nil
+		},
+		timeout:  time.Second,
+		throttle: newThrottle(time.Minute),
+	})
+
+	logger.NewEntry().WithThrottleKey("user-1").Error("sync failed")
+	logger.NewEntry().WithThrottleKey("user-2").Error("sync failed")
+	assert.Equal(t, 2, notified, "different throttle keys are not collapsed")
+
+	logger.NewEntry().WithThrottleKey("user-1").Error("sync failed again")
+	logger.Error("sync failed")
+	logger.Error("sync failed")
+	assert.Equal(t, 3, notified, "without a key the message is throttled on")
+
+	now = now.Add(time.Minute)
+	logger.NewEntry().WithThrottleKey("user-1").Error("sync failed")
+	assert.Equal(t, 4, notified)
+}
+
+func TestThrottleBoundsKeys(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	throttle := newThrottle(time.Minute)
+	for i := 0; i < maxThrottleKeys+10; i++ {
+		assert.True(t, throttle.allow(fmt.Sprint("key-", i)))
+	}
+	assert.Len(t, throttle.keys, maxThrottleKeys)
+	assert.Equal(t, maxThrottleKeys, throttle.order.Len())
+	assert.True(t, throttle.allow("key-0"), "the oldest keys are forgotten")
+	assert.False(t, throttle.allow(fmt.Sprint("key-", maxThrottleKeys+9)))
+
+	now = now.Add(time.Minute)
+	assert.True(t, throttle.allow("key-new"))
+	assert.Len(t, throttle.keys, 1, "expired keys are swept")
+}
+
+func TestBugsnagThrottleSkipsOpenCircuit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	notified := 0
+	breaker := newCircuitBreaker(1, time.Minute)
+	breaker.allow()
+	breaker.record(errors.New("bugsnag down"))
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(error, ...interface{}) error {
+			notified++
+			return nil
+		},
+		timeout:  time.Second,
+		breaker:  breaker,
+		throttle: newThrottle(time.Hour),
+	})
+
+	logger.Error("sync failed")
+	assert.Zero(t, notified, "the open circuit skips the notify")
+
+	now = now.Add(time.Minute)
+	logger.Error("sync failed")
+	assert.Equal(t, 1, notified, "errors skipped by the circuit don't use up the throttle")
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestBugsnagThrottleReleasesProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	SetClockForTest(func() time.Time { return now })
+	defer SetClockForTest(nil)
+
+	notified := 0
+	breaker := newCircuitBreaker(1, time.Minute)
+	breaker.allow()
+	breaker.record(errors.New("bugsnag down"))
+	throttle := newThrottle(time.Hour)
+	throttle.allow("sync failed")
+	logger := new(false, LoggingConfig{})
+	logger.Out = io.Discard
+	logger.Hooks.Add(&bugsnagHook{
+		notify: func(error, ...interface{}) error {
+			notified++
+			return nil
+		},
+		timeout:  time.Second,
+		breaker:  breaker,
+		throttle: throttle,
+	})
+
+	now = now.Add(time.Minute)
+	logger.Error("sync failed")
+	assert.Zero(t, notified)
+	logger.Error("upload failed")
+	assert.Equal(t, 1, notified, "a throttled notify gives its half-open probe back")
+	assert.Equal(t, CircuitClosed, breaker.State())
+}